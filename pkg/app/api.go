@@ -0,0 +1,173 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/rpc"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/watcher"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultAPIBlocks = 20
+	maxAPIBlocks     = 100
+)
+
+// API exposes the current view of the watchers as JSON under /api/v1.
+//
+// Watchers of disabled features are nil and their endpoints respond 404.
+type API struct {
+	Validators []watcher.TrackedValidator
+	Pool       *rpc.Pool
+
+	BlockWatcher       *watcher.BlockWatcher
+	ValidatorsWatcher  *watcher.ValidatorsWatcher
+	CommissionsWatcher *watcher.CommissionWatcher
+	VotesWatcher       *watcher.VotesWatcher
+	UpgradeWatcher     *watcher.UpgradeWatcher
+	SlashingWatcher    *watcher.SlashingWatcher
+	BabylonWatcher     *watcher.BabylonWatcher
+}
+
+type apiNode struct {
+	Endpoint    string                     `json:"endpoint"`
+	ChainID     string                     `json:"chain_id"`
+	Synced      bool                       `json:"synced"`
+	Height      int64                      `json:"height"`
+	Lag         int64                      `json:"lag"`
+	Lagging     bool                       `json:"lagging"`
+	CircuitOpen bool                       `json:"circuit_open"`
+	Quarantine  []string                   `json:"quarantine"`
+	Divergences map[string]*rpc.Divergence `json:"divergences"`
+}
+
+func WithAPI(api *API) HTTPMuxOption {
+	return func(mux *http.ServeMux) {
+		mux.HandleFunc("GET /api/v1/validators", api.handleValidators)
+		mux.HandleFunc("GET /api/v1/blocks", api.handleBlocks)
+		mux.HandleFunc("GET /api/v1/proposals", api.handleProposals)
+		mux.HandleFunc("GET /api/v1/upgrade", api.handleUpgrade)
+		mux.HandleFunc("GET /api/v1/slashing", api.handleSlashing)
+		mux.HandleFunc("GET /api/v1/nodes", api.handleNodes)
+		mux.HandleFunc("GET /api/v1/babylon", api.handleBabylon)
+	}
+}
+
+func (a *API) handleValidators(w http.ResponseWriter, r *http.Request) {
+	var validators []watcher.ValidatorState
+	if a.ValidatorsWatcher != nil {
+		validators = a.ValidatorsWatcher.Validators()
+	} else {
+		// Staking module disabled: only the tracked addresses are known
+		for _, val := range a.Validators {
+			validators = append(validators, watcher.ValidatorState{
+				Address:          val.Address,
+				Name:             val.Name,
+				Moniker:          val.Moniker,
+				OperatorAddress:  val.OperatorAddress,
+				ConsensusAddress: val.ConsensusAddress,
+			})
+		}
+	}
+
+	if a.CommissionsWatcher != nil {
+		for i := range validators {
+			validators[i].Commission = a.CommissionsWatcher.Commissions(validators[i].Address)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, validators)
+}
+
+func (a *API) handleBlocks(w http.ResponseWriter, r *http.Request) {
+	if a.BlockWatcher == nil {
+		writeError(w, http.StatusNotFound, "block watcher disabled")
+		return
+	}
+
+	limit := defaultAPIBlocks
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = min(parsed, maxAPIBlocks)
+	}
+
+	writeJSON(w, http.StatusOK, a.BlockWatcher.RecentBlocks(limit))
+}
+
+func (a *API) handleProposals(w http.ResponseWriter, r *http.Request) {
+	if a.VotesWatcher == nil {
+		writeError(w, http.StatusNotFound, "gov module disabled")
+		return
+	}
+
+	proposals := a.VotesWatcher.Proposals()
+	if proposals == nil {
+		proposals = []watcher.ProposalState{}
+	}
+	writeJSON(w, http.StatusOK, proposals)
+}
+
+func (a *API) handleUpgrade(w http.ResponseWriter, r *http.Request) {
+	if a.UpgradeWatcher == nil {
+		writeError(w, http.StatusNotFound, "upgrade module disabled")
+		return
+	}
+
+	// null when no upgrade is planned
+	writeJSON(w, http.StatusOK, a.UpgradeWatcher.Upgrade())
+}
+
+func (a *API) handleSlashing(w http.ResponseWriter, r *http.Request) {
+	if a.SlashingWatcher == nil {
+		writeError(w, http.StatusNotFound, "slashing module disabled")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, a.SlashingWatcher.Params())
+}
+
+func (a *API) handleBabylon(w http.ResponseWriter, r *http.Request) {
+	if a.BabylonWatcher == nil {
+		writeError(w, http.StatusNotFound, "babylon disabled")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, a.BabylonWatcher.State())
+}
+
+func (a *API) handleNodes(w http.ResponseWriter, r *http.Request) {
+	nodes := make([]apiNode, len(a.Pool.Nodes))
+	for i, node := range a.Pool.Nodes {
+		nodes[i] = apiNode{
+			Endpoint:    node.Redacted(),
+			ChainID:     node.ChainID(),
+			Synced:      node.IsSynced(),
+			Height:      node.LatestHeight(),
+			Lag:         node.Lag(),
+			Lagging:     node.IsLagging(),
+			CircuitOpen: node.IsCircuitOpen(),
+			Quarantine:  node.QuarantineReasons(),
+			Divergences: node.Divergences(),
+		}
+	}
+
+	writeJSON(w, http.StatusOK, nodes)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Error().Err(err).Msg("failed to encode api response")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
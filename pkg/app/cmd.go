@@ -24,4 +24,11 @@ var Commands = []*cli.Command{
 			},
 		},
 	},
+	{
+		Name:      "report",
+		Usage:     "Uptime report of the validators over a height or time range",
+		UsageText: "cosmos-validator-watcher report --validator <address> --node <archive-node> --from-time 2024-01-01 --to-time 2024-02-01",
+		Action:    ReportRun,
+		Flags:     ReportFlags,
+	},
 }
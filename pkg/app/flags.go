@@ -4,10 +4,21 @@ import (
 	"sort"
 	"time"
 
+	"github.com/samber/lo"
 	"github.com/urfave/cli/v2"
 )
 
 var Flags = []cli.Flag{
+	&cli.IntFlag{
+		Name:  "backfill-concurrency",
+		Usage: "number of missed blocks fetched in parallel",
+		Value: 4,
+	},
+	&cli.Int64Flag{
+		Name:  "backfill-depth",
+		Usage: "maximum number of missed blocks fetched when a node falls behind (eg. after a websocket outage)",
+		Value: 20,
+	},
 	&cli.StringFlag{
 		Name:  "chain-id",
 		Usage: "to ensure all nodes matches the specific network (dismiss to auto-detected)",
@@ -16,6 +27,28 @@ var Flags = []cli.Flag{
 		Name:  "debug",
 		Usage: "shortcut for --log-level=debug",
 	},
+	&cli.StringFlag{
+		Name:  "expected-votes",
+		Usage: "file with the expected vote for each proposal (one <proposal-id>:<option> per line)",
+	},
+	&cli.Int64Flag{
+		Name:  "from-height",
+		Usage: "replay the blocks since the given height instead of watching live blocks (or first height of the report)",
+	},
+	&cli.DurationFlag{
+		Name:  "halt-threshold",
+		Usage: "time without new blocks before considering the chain halted",
+		Value: 2 * time.Minute,
+	},
+	&cli.StringFlag{
+		Name:  "history-path",
+		Usage: "directory of the signing history store, used to resume counters after a restart (disabled if empty)",
+	},
+	&cli.DurationFlag{
+		Name:  "history-retention",
+		Usage: "how long to keep the signing history (0 to keep forever)",
+		Value: 30 * 24 * time.Hour,
+	},
 	&cli.StringFlag{
 		Name:  "http-addr",
 		Usage: "http server address",
@@ -26,6 +59,11 @@ var Flags = []cli.Flag{
 		Usage: "log level (debug, info, warn, error)",
 		Value: "info",
 	},
+	&cli.Int64Flag{
+		Name:  "max-node-lag",
+		Usage: "number of blocks a node can lag behind the other nodes before being excluded (0 to disable)",
+		Value: 10,
+	},
 	&cli.StringFlag{
 		Name:  "namespace",
 		Usage: "namespace for Prometheus metrics",
@@ -68,6 +106,43 @@ var Flags = []cli.Flag{
 		Name:  "denom-exponent",
 		Usage: "denom exponent (eg. 6 for atom, 1 for uatom)",
 	},
+	&cli.StringFlag{
+		Name:  "event-queue-policy",
+		Usage: "behavior when the event queue of a watcher is full (drop-oldest, drop-newest or block)",
+		Value: "drop-oldest",
+	},
+	&cli.IntFlag{
+		Name:  "event-queue-size",
+		Usage: "number of node events queued for each watcher",
+		Value: 100,
+	},
+	&cli.DurationFlag{
+		Name:  "query-cache-ttl",
+		Usage: "duration the module query responses are cached and shared between watchers (0 to disable)",
+		Value: 15 * time.Second,
+	},
+	&cli.StringSliceFlag{
+		Name:  "ready-watcher",
+		Usage: "watcher required to be healthy for the readiness probe (block, status, halt, commissions, validators, votes, upgrade, slashing or babylon)",
+	},
+	&cli.IntFlag{
+		Name:  "rpc-breaker-threshold",
+		Usage: "number of consecutive failed requests before taking a node out of use (0 to disable)",
+		Value: 5,
+	},
+	&cli.DurationFlag{
+		Name:  "rpc-breaker-cooldown",
+		Usage: "time a failing node is taken out of use before being probed again",
+		Value: 30 * time.Second,
+	},
+	&cli.Float64Flag{
+		Name:  "rpc-rate-limit",
+		Usage: "maximum number of requests per second sent to each node (0 for unlimited, override per node with __rate_limit)",
+	},
+	&cli.Int64Flag{
+		Name:  "start-height",
+		Usage: "fetch all the blocks since the given height on startup (eg. to resume after a restart)",
+	},
 	&cli.DurationFlag{
 		Name:  "start-timeout",
 		Usage: "timeout to wait on startup for one node to be ready",
@@ -78,10 +153,22 @@ var Flags = []cli.Flag{
 		Usage: "timeout to wait on stop",
 		Value: 10 * time.Second,
 	},
+	&cli.Int64Flag{
+		Name:  "to-height",
+		Usage: "last height to replay or report (0 for the latest height)",
+	},
+	&cli.StringSliceFlag{
+		Name:  "upgrade-reminder",
+		Usage: "send an upgrade reminder webhook at a given time before the estimated upgrade (eg. 24h, 1h, 10m)",
+	},
 	&cli.StringSliceFlag{
 		Name:  "validator",
 		Usage: "validator address(es) to track (use :my-label to add a custom label in metrics & output)",
 	},
+	&cli.StringSliceFlag{
+		Name:  "validator-node",
+		Usage: "node id of a validator node or sentry to check in the peers of the nodes (<validator>:<validator|sentry>:<node-id>)",
+	},
 	&cli.StringFlag{
 		Name:  "webhook-url",
 		Usage: "endpoint where to send upgrade webhooks (experimental)",
@@ -105,8 +192,36 @@ var Flags = []cli.Flag{
 	},
 }
 
-func init() {
-	sort.SliceStable(Flags, func(i, j int) bool {
-		return Flags[i].Names()[0] < Flags[j].Names()[0]
+// ReportFlags are the flags of the report command (only the flags it reads).
+var ReportFlags = append(flagsNamed(
+	"backfill-concurrency", "chain-id", "from-height", "history-path", "node", "to-height", "validator",
+), []cli.Flag{
+	&cli.StringFlag{
+		Name:  "format",
+		Usage: "report format (table, csv, json)",
+		Value: "table",
+	},
+	&cli.StringFlag{
+		Name:  "from-time",
+		Usage: "start time of the report (RFC3339 or YYYY-MM-DD, instead of --from-height)",
+	},
+	&cli.StringFlag{
+		Name:  "to-time",
+		Usage: "end time of the report (RFC3339 or YYYY-MM-DD, instead of --to-height)",
+	},
+}...)
+
+// flagsNamed returns the flags of Flags with the given names.
+func flagsNamed(names ...string) []cli.Flag {
+	return lo.Filter(Flags, func(flag cli.Flag, _ int) bool {
+		return lo.Contains(names, flag.Names()[0])
 	})
 }
+
+func init() {
+	for _, flags := range [][]cli.Flag{Flags, ReportFlags} {
+		sort.SliceStable(flags, func(i, j int) bool {
+			return flags[i].Names()[0] < flags[j].Names()[0]
+		})
+	}
+}
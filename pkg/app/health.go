@@ -0,0 +1,44 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/watcher"
+)
+
+// HealthChecks holds the health of the running watchers.
+type HealthChecks []*watcher.Health
+
+type healthResponse struct {
+	Healthy  bool                   `json:"healthy"`
+	Watchers []watcher.HealthStatus `json:"watchers"`
+}
+
+// Get returns the health of a watcher by name (nil if not running).
+func (c HealthChecks) Get(name string) *watcher.Health {
+	for _, health := range c {
+		if health.Name() == name {
+			return health
+		}
+	}
+	return nil
+}
+
+// WithHealth exposes the health of each watcher, responding 503 if any is unhealthy.
+func WithHealth(checks HealthChecks) HTTPMuxOption {
+	return func(mux *http.ServeMux) {
+		mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+			resp := healthResponse{Healthy: true, Watchers: make([]watcher.HealthStatus, len(checks))}
+			for i, health := range checks {
+				resp.Watchers[i] = health.Status()
+				resp.Healthy = resp.Healthy && resp.Watchers[i].Healthy
+			}
+
+			status := http.StatusOK
+			if !resp.Healthy {
+				status = http.StatusServiceUnavailable
+			}
+			writeJSON(w, status, resp)
+		})
+	}
+}
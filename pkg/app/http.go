@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/dashboard"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/stream"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -26,16 +28,31 @@ func withProbe(path string, probe Probe) HTTPMuxOption {
 }
 
 func WithReadyProbe(probe Probe) HTTPMuxOption {
-	return withProbe("/ready", probe)
+	return withProbe("GET /ready", probe)
 }
 
 func WithLiveProbe(probe Probe) HTTPMuxOption {
-	return withProbe("/live", probe)
+	return withProbe("GET /live", probe)
 }
 
 func WithMetrics(registry *prometheus.Registry) HTTPMuxOption {
 	return func(mux *http.ServeMux) {
-		mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+		mux.Handle("GET /metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	}
+}
+
+func WithStream(hub *stream.Hub) HTTPMuxOption {
+	return func(mux *http.ServeMux) {
+		mux.HandleFunc("GET /api/v1/stream", hub.SSEHandler())
+		mux.HandleFunc("GET /api/v1/ws", hub.WebSocketHandler())
+	}
+}
+
+// WithDashboard serves the dashboard on all the other paths (the other routes
+// must be GET patterns to not conflict with it).
+func WithDashboard() HTTPMuxOption {
+	return func(mux *http.ServeMux) {
+		mux.Handle("GET /", dashboard.Handler())
 	}
 }
 
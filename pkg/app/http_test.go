@@ -0,0 +1,43 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/stream"
+	"gotest.tools/assert"
+)
+
+func TestHTTPServer(t *testing.T) {
+	hub := stream.NewHub()
+	defer hub.Close()
+
+	// Same routes as the run command
+	server := NewHTTPServer(
+		":0",
+		WithReadyProbe(upProbe),
+		WithLiveProbe(upProbe),
+		WithMetrics(metrics.New("cosmos_validator_watcher").Registry),
+		WithHealth(HealthChecks{}),
+		WithAPI(&API{}),
+		WithStream(hub),
+		WithDashboard(),
+	)
+
+	for path, status := range map[string]int{
+		"/ready":          http.StatusNoContent,
+		"/live":           http.StatusNoContent,
+		"/metrics":        http.StatusOK,
+		"/health":         http.StatusOK,
+		"/api/v1/upgrade": http.StatusNotFound,
+		"/":               http.StatusOK,
+		"/app.js":         http.StatusOK,
+		"/missing.js":     http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, status, rec.Code, path)
+	}
+}
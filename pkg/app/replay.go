@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"os"
+
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cometbft/cometbft/types"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/rpc"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/watcher"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+)
+
+type replayOptions struct {
+	FromHeight int64
+	ToHeight   int64
+
+	Babylon           bool
+	FinalityProviders []watcher.BabylonFinalityProvider
+}
+
+// runReplay runs the block (and babylon) watchers over a historical height
+// range instead of live events, and stops at the end of the range.
+func runReplay(ctx context.Context, pool *rpc.Pool, metrics *metrics.Metrics, trackedValidators []watcher.TrackedValidator, options replayOptions) error {
+	errg, ctx := errgroup.WithContext(ctx)
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	// Close the node connections once replayed
+	defer func() {
+		if err := pool.Stop(context.Background()); err != nil {
+			log.Error().Err(err).Msg("failed to stop node pool")
+		}
+	}()
+
+	blockWatcher := watcher.NewBlockWatcher(trackedValidators, metrics, os.Stdout, nil, nil, nil)
+	errg.Go(func() error {
+		return blockWatcher.Start(ctx)
+	})
+	// Replayed blocks are old, they would be ignored by the live handlers
+	pool.OnNodeEvent(rpc.EventNewBlock, replayValidatorSet(blockWatcher))
+
+	if options.Babylon {
+		babylonWatcher := watcher.NewBabylonWatcher(trackedValidators, options.FinalityProviders, pool, metrics, os.Stdout)
+		errg.Go(func() error {
+			return babylonWatcher.Start(ctx)
+		})
+		pool.OnNodeEvent(rpc.EventNewBlock, babylonWatcher.OnReplayedBlock)
+	}
+
+	// The signatures of the last height are included in the next block
+	toHeight := options.ToHeight
+	if toHeight > 0 {
+		toHeight++
+	}
+
+	errg.Go(func() error {
+		// Blocks are handled synchronously, the watchers can stop once replayed
+		defer stop()
+		return pool.Replay(ctx, options.FromHeight, toHeight)
+	})
+
+	if err := errg.Wait(); err != nil {
+		return err
+	}
+
+	log.Info().Msg("replay completed")
+	return nil
+}
+
+// replayValidatorSet returns a block callback checking the signatures of each
+// replayed block against the validator set of its height (instead of the
+// current one), fetched each time the set changes.
+func replayValidatorSet(blockWatcher *watcher.BlockWatcher) rpc.OnNodeEvent {
+	var (
+		previous       *types.Block
+		validatorsHash string
+	)
+
+	return func(ctx context.Context, node *rpc.Node, evt *ctypes.ResultEvent) error {
+		block := evt.Data.(types.EventDataNewBlock).Block
+
+		// The last commit is signed by the validators of the previous height
+		if height := block.Height - 1; height > 0 {
+			contiguous := previous != nil && previous.Height == height
+			if !contiguous || previous.ValidatorsHash.String() != validatorsHash {
+				validatorSet, err := fetchValidatorSet(ctx, node, height)
+				if err != nil {
+					validatorsHash = ""
+					log.Error().Err(err).Msg("failed to sync validator set")
+				} else {
+					blockWatcher.SetValidatorSet(validatorSet)
+					validatorsHash = ""
+					if contiguous {
+						validatorsHash = previous.ValidatorsHash.String()
+					}
+				}
+			}
+		}
+		previous = block
+
+		return blockWatcher.OnReplayedBlock(ctx, node, evt)
+	}
+}
@@ -0,0 +1,235 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cometbft/cometbft/types"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/history"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/rpc"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/watcher"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"github.com/urfave/cli/v2"
+)
+
+type reportRange struct {
+	FromHeight int64
+	ToHeight   int64
+	FromTime   time.Time
+	ToTime     time.Time
+}
+
+// ReportRun prints the uptime of the validators over a height or time range,
+// from the local history store (--history-path) or by replaying the blocks
+// of an archive node.
+func ReportRun(cCtx *cli.Context) error {
+	var (
+		ctx = cCtx.Context
+
+		chainID     = cCtx.String("chain-id")
+		concurrency = cCtx.Int("backfill-concurrency")
+		format      = cCtx.String("format")
+		historyPath = cCtx.String("history-path")
+		nodes       = cCtx.StringSlice("node")
+		validators  = cCtx.StringSlice("validator")
+	)
+
+	if len(validators) < 1 {
+		return cli.Exit("at least one validator must be specified", 1)
+	}
+
+	reportRange := reportRange{
+		FromHeight: cCtx.Int64("from-height"),
+		ToHeight:   cCtx.Int64("to-height"),
+	}
+	for name, t := range map[string]*time.Time{"from-time": &reportRange.FromTime, "to-time": &reportRange.ToTime} {
+		if cCtx.String(name) == "" {
+			continue
+		}
+		parsed, err := parseReportTime(cCtx.String(name))
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("invalid --%s: %s", name, err), 1)
+		}
+		*t = parsed
+	}
+
+	trackedValidators := lo.Map(validators, func(v string, _ int) watcher.TrackedValidator {
+		return watcher.ParseValidator(v)
+	})
+
+	summary := history.NewSummary()
+	for _, val := range trackedValidators {
+		summary.Validator(val.Address, val.Name)
+	}
+
+	var err error
+	if historyPath != "" {
+		err = reportFromHistory(historyPath, chainID, reportRange, summary)
+	} else if len(nodes) > 0 {
+		err = reportFromNode(ctx, nodes[0], concurrency, reportRange, trackedValidators, summary)
+	} else {
+		return cli.Exit("either --history-path or --node must be specified", 1)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Only report the requested validators
+	summary.Validators = lo.Filter(summary.Validators, func(val *history.ValidatorSummary, _ int) bool {
+		return lo.ContainsBy(trackedValidators, func(tv watcher.TrackedValidator) bool { return tv.Address == val.Address })
+	})
+
+	return summary.Write(cCtx.App.Writer, format)
+}
+
+func parseReportTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+func reportFromHistory(path string, chainID string, reportRange reportRange, summary *history.Summary) error {
+	if chainID == "" {
+		return cli.Exit("--chain-id must be specified to read the history store", 1)
+	}
+
+	store, err := history.Open(path, chainID, 0)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	return store.Range(reportRange.FromHeight, reportRange.ToHeight, func(record history.BlockRecord) error {
+		if !reportRange.FromTime.IsZero() && record.Time.Before(reportRange.FromTime) {
+			return nil
+		}
+		if !reportRange.ToTime.IsZero() && !record.Time.Before(reportRange.ToTime) {
+			return nil
+		}
+		summary.Add(record)
+		return nil
+	})
+}
+
+// reportFromNode replays the blocks of the range from an archive node.
+func reportFromNode(ctx context.Context, endpoint string, concurrency int, reportRange reportRange, trackedValidators []watcher.TrackedValidator, summary *history.Summary) error {
+	endpoint, clientOpts, err := rpc.ParseEndpoint(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid node endpoint: %w", err)
+	}
+	client, err := rpc.NewClient(endpoint, clientOpts)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	node := rpc.NewNode(client, rpc.BackfillConcurrency(concurrency))
+
+	status, err := client.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get node status: %w", err)
+	}
+	earliest, latest := status.SyncInfo.EarliestBlockHeight, status.SyncInfo.LatestBlockHeight
+
+	from, to := reportRange.FromHeight, reportRange.ToHeight
+	if !reportRange.FromTime.IsZero() {
+		if from, err = heightAtTime(ctx, node, earliest, latest, reportRange.FromTime); err != nil {
+			return err
+		}
+	}
+	if !reportRange.ToTime.IsZero() {
+		if to, err = heightAtTime(ctx, node, earliest, latest, reportRange.ToTime); err != nil {
+			return err
+		}
+		to-- // the end time is exclusive
+	}
+	// The signatures of the last height are in the next block
+	if to <= 0 || to >= latest {
+		to = latest - 1
+	}
+	// Without a start, report since the earliest block available on the node
+	if from <= 0 {
+		from = earliest
+	}
+	if from < earliest {
+		return fmt.Errorf("node does not have blocks before height %d (use an archive node)", earliest)
+	}
+	if from > to {
+		return fmt.Errorf("invalid range: %d to %d", from, to)
+	}
+
+	log.Info().Int64("from", from).Int64("to", to).Msgf("replaying blocks from %s", node.Redacted())
+
+	var (
+		previous       *types.Block
+		previousInfo   *watcher.BlockInfo
+		validatorSet   []*types.Validator
+		validatorsHash string
+		handleErr      error
+	)
+	node.FetchBlocks(ctx, from, to+1, func(block *types.Block) {
+		if handleErr != nil {
+			return
+		}
+
+		// The last commit is signed by the validators of the previous height
+		if previous != nil && previous.ValidatorsHash.String() != validatorsHash {
+			validatorSet, handleErr = fetchValidatorSet(ctx, node, previous.Height)
+			validatorsHash = previous.ValidatorsHash.String()
+		}
+
+		info := watcher.NewBlockInfo(block, watcher.ComputeValidatorStatus(block, trackedValidators, validatorSet))
+		if previousInfo != nil && previousInfo.Height == block.Height-1 {
+			summary.Add(watcher.NewBlockRecord(previousInfo, info))
+		}
+
+		previous, previousInfo = block, info
+		if block.Height%1000 == 0 {
+			log.Info().Int64("height", block.Height).Msg("replaying blocks")
+		}
+	})
+	if handleErr != nil {
+		return handleErr
+	}
+
+	if expected := int(to - from + 1); summary.Blocks < expected {
+		log.Warn().Msgf("%d blocks could not be fetched, the report is incomplete", expected-summary.Blocks)
+	}
+
+	return nil
+}
+
+// heightAtTime returns the first height with a block time after the given time.
+func heightAtTime(ctx context.Context, node *rpc.Node, low, high int64, t time.Time) (int64, error) {
+	for low < high {
+		mid := low + (high-low)/2
+		resp, err := node.Client.Header(ctx, &mid)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get header at height %d: %w", mid, err)
+		}
+		if resp.Header.Time.Before(t) {
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+	return low, nil
+}
+
+func fetchValidatorSet(ctx context.Context, node *rpc.Node, height int64) ([]*types.Validator, error) {
+	validators := make([]*types.Validator, 0)
+
+	for page := 1; ; page++ {
+		perPage := 100
+		result, err := node.Client.Validators(ctx, &height, &page, &perPage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get validators at height %d: %w", height, err)
+		}
+		validators = append(validators, result.Validators...)
+
+		if len(validators) >= result.Total || len(result.Validators) == 0 {
+			return validators, nil
+		}
+	}
+}
@@ -6,18 +6,21 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"runtime"
 	"strconv"
 	"syscall"
+	"time"
 
 	upgrade "cosmossdk.io/x/upgrade/types"
-	"github.com/cometbft/cometbft/rpc/client/http"
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/types/query"
 	staking "github.com/cosmos/cosmos-sdk/x/staking/types"
 	"github.com/fatih/color"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/crypto"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/history"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/rpc"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/stream"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/watcher"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/webhook"
 	"github.com/rs/zerolog"
@@ -32,10 +35,20 @@ func RunFunc(cCtx *cli.Context) error {
 		ctx = cCtx.Context
 
 		// Config flags
+		backfillConcurrency = cCtx.Int("backfill-concurrency")
+		backfillDepth       = cCtx.Int64("backfill-depth")
 		chainID             = cCtx.String("chain-id")
 		debug               = cCtx.Bool("debug")
+		eventQueuePolicy    = cCtx.String("event-queue-policy")
+		eventQueueSize      = cCtx.Int("event-queue-size")
+		expectedVotes       = cCtx.String("expected-votes")
+		fromHeight          = cCtx.Int64("from-height")
+		haltThreshold       = cCtx.Duration("halt-threshold")
+		historyPath         = cCtx.String("history-path")
+		historyRetention    = cCtx.Duration("history-retention")
 		httpAddr            = cCtx.String("http-addr")
 		logLevel            = cCtx.String("log-level")
+		maxNodeLag          = cCtx.Int64("max-node-lag")
 		namespace           = cCtx.String("namespace")
 		noColor             = cCtx.Bool("no-color")
 		nodes               = cCtx.StringSlice("node")
@@ -46,9 +59,18 @@ func RunFunc(cCtx *cli.Context) error {
 		noSlashing          = cCtx.Bool("no-slashing")
 		denom               = cCtx.String("denom")
 		denomExpon          = cCtx.Uint("denom-exponent")
+		queryCacheTTL       = cCtx.Duration("query-cache-ttl")
+		readyWatchers       = cCtx.StringSlice("ready-watcher")
+		rpcBreakerThreshold = cCtx.Int("rpc-breaker-threshold")
+		rpcBreakerCooldown  = cCtx.Duration("rpc-breaker-cooldown")
+		rpcRateLimit        = cCtx.Float64("rpc-rate-limit")
+		startHeight         = cCtx.Int64("start-height")
 		startTimeout        = cCtx.Duration("start-timeout")
 		stopTimeout         = cCtx.Duration("stop-timeout")
+		toHeight            = cCtx.Int64("to-height")
+		upgradeReminders    = cCtx.StringSlice("upgrade-reminder")
 		validators          = cCtx.StringSlice("validator")
+		validatorNodes      = cCtx.StringSlice("validator-node")
 		webhookURL          = cCtx.String("webhook-url")
 		webhookCustomBlocks = cCtx.StringSlice("webhook-custom-block")
 		xGov                = cCtx.String("x-gov")
@@ -83,8 +105,21 @@ func RunFunc(cCtx *cli.Context) error {
 	startCtx, cancel := context.WithTimeout(ctx, startTimeout)
 	defer cancel()
 
+	metrics := metrics.New(namespace)
+	metrics.Register()
+	metrics.BuildInfo.WithLabelValues(cCtx.App.Version, runtime.Version()).Set(1)
+
 	// Test connection to nodes
-	pool, err := createNodePool(startCtx, nodes)
+	pool, err := createNodePool(startCtx, metrics, nodes, chainID, nodePoolOptions{
+		MaxLag:              maxNodeLag,
+		RateLimit:           rpcRateLimit,
+		BreakerThreshold:    rpcBreakerThreshold,
+		BreakerCooldown:     rpcBreakerCooldown,
+		BackfillDepth:       backfillDepth,
+		BackfillConcurrency: backfillConcurrency,
+		StartHeight:         startHeight,
+		QueryCacheTTL:       queryCacheTTL,
+	})
 	if err != nil {
 		return err
 	}
@@ -114,6 +149,18 @@ func RunFunc(cCtx *cli.Context) error {
 		return err
 	}
 
+	// Replay mode
+	if fromHeight > 0 {
+		return runReplay(ctx, pool, metrics, trackedValidators, replayOptions{
+			FromHeight: fromHeight,
+			ToHeight:   toHeight,
+			Babylon:    babylonEnabled,
+			FinalityProviders: lo.Map(finalityProviders, func(val string, _ int) watcher.BabylonFinalityProvider {
+				return watcher.ParseBabylonFinalityProvider(val)
+			}),
+		})
+	}
+
 	var wh *webhook.Webhook
 	if webhookURL != "" {
 		whURL, err := url.Parse(webhookURL)
@@ -136,21 +183,85 @@ func RunFunc(cCtx *cli.Context) error {
 		})
 	}
 
+	// Upgrade reminders
+	reminderLeadTimes := []time.Duration{}
+	for _, reminder := range upgradeReminders {
+		lead, err := time.ParseDuration(reminder)
+		if err != nil {
+			return fmt.Errorf("failed to parse upgrade reminder (%s): %w", reminder, err)
+		}
+		reminderLeadTimes = append(reminderLeadTimes, lead)
+	}
+
+	// Signing history
+	var historyStore *history.Store
+	if historyPath != "" {
+		historyStore, err = history.Open(historyPath, pool.ChainID, historyRetention)
+		if err != nil {
+			return err
+		}
+		defer historyStore.Close()
+		errg.Go(func() error {
+			return historyStore.Start(ctx)
+		})
+	}
+
 	//
 	// Node Watchers
 	//
-	metrics := metrics.New(namespace)
-	metrics.Register()
-	blockWatcher := watcher.NewBlockWatcher(trackedValidators, metrics, os.Stdout, wh, blockWebhooks)
+	// Node events are queued for each watcher, so a slow watcher does not stall the nodes
+	queuePolicy, err := rpc.ParseQueuePolicy(eventQueuePolicy)
+	if err != nil {
+		return err
+	}
+	dispatch := func(name string, callback rpc.OnNodeEvent) rpc.OnNodeEvent {
+		dispatcher := rpc.NewDispatcher(name, callback, rpc.DispatcherOptions{
+			QueueSize: eventQueueSize,
+			Policy:    queuePolicy,
+			Metrics:   metrics,
+		})
+		errg.Go(func() error {
+			return dispatcher.Start(ctx)
+		})
+		return dispatcher.OnNodeEvent
+	}
+
+	hub := stream.NewHub()
+	blockWatcher := watcher.NewBlockWatcher(trackedValidators, metrics, os.Stdout, wh, blockWebhooks, historyStore)
+	blockWatcher.OnBlock(func(record history.BlockRecord) {
+		hub.Publish(stream.EventBlock, record)
+	})
 	errg.Go(func() error {
 		return blockWatcher.Start(ctx)
 	})
-	statusWatcher := watcher.NewStatusWatcher(chainID, metrics)
+	api := &API{Validators: trackedValidators, Pool: pool, BlockWatcher: blockWatcher}
+	statusWatcher := watcher.NewStatusWatcher(chainID, metrics, wh)
 	errg.Go(func() error {
 		return statusWatcher.Start(ctx)
 	})
+	if len(validatorNodes) > 0 {
+		nodes := make([]watcher.ValidatorNode, len(validatorNodes))
+		for i, val := range validatorNodes {
+			nodes[i], err = watcher.ParseValidatorNode(val)
+			if err != nil {
+				return err
+			}
+		}
+		topologyWatcher := watcher.NewTopologyWatcher(trackedValidators, nodes, metrics, wh)
+		statusWatcher.OnNodeNetInfo(topologyWatcher.OnNodeNetInfo)
+	}
+	haltWatcher := watcher.NewHaltWatcher(metrics, pool, wh, watcher.HaltWatcherOptions{
+		Threshold:    haltThreshold,
+		CheckUpgrade: !noUpgrade,
+	})
+	errg.Go(func() error {
+		return haltWatcher.Start(ctx)
+	})
+	healthChecks := HealthChecks{blockWatcher.Health(), statusWatcher.Health(), haltWatcher.Health()}
 	if !noCommission {
 		commissionWatcher := watcher.NewCommissionsWatcher(trackedValidators, metrics, pool)
+		api.CommissionsWatcher = commissionWatcher
+		healthChecks = append(healthChecks, commissionWatcher.Health())
 		errg.Go(func() error {
 			return commissionWatcher.Start(ctx)
 		})
@@ -160,10 +271,18 @@ func RunFunc(cCtx *cli.Context) error {
 			return watcher.ParseBabylonFinalityProvider(val)
 		})
 		babylonWatcher := watcher.NewBabylonWatcher(trackedValidators, finalityProviders, pool, metrics, os.Stdout)
+		api.BabylonWatcher = babylonWatcher
+		babylonWatcher.OnFinality(func(finality watcher.BabylonFinality) {
+			hub.Publish(stream.EventBabylonFinality, finality)
+		})
+		babylonWatcher.OnCheckpoint(func(checkpoint watcher.BabylonCheckpoint) {
+			hub.Publish(stream.EventBabylonCheckpoint, checkpoint)
+		})
+		healthChecks = append(healthChecks, babylonWatcher.Health())
 		errg.Go(func() error {
 			return babylonWatcher.Start(ctx)
 		})
-		pool.OnNodeEvent(rpc.EventNewBlock, babylonWatcher.OnNewBlock)
+		pool.OnNodeEvent(rpc.EventNewBlock, dispatch("babylon", babylonWatcher.OnNewBlock))
 	}
 
 	//
@@ -171,6 +290,8 @@ func RunFunc(cCtx *cli.Context) error {
 	//
 	if !noSlashing {
 		slashingWatcher := watcher.NewSlashingWatcher(metrics, pool)
+		api.SlashingWatcher = slashingWatcher
+		healthChecks = append(healthChecks, slashingWatcher.Health())
 		errg.Go(func() error {
 			return slashingWatcher.Start(ctx)
 		})
@@ -185,6 +306,8 @@ func RunFunc(cCtx *cli.Context) error {
 			DenomExponent: denomExpon,
 			NoSlashing:    noSlashing,
 		})
+		api.ValidatorsWatcher = validatorsWatcher
+		healthChecks = append(healthChecks, validatorsWatcher.Health())
 		errg.Go(func() error {
 			return validatorsWatcher.Start(ctx)
 		})
@@ -193,10 +316,21 @@ func RunFunc(cCtx *cli.Context) error {
 		log.Warn().Msgf("unknown gov module version: %s (fallback to v1)", xGov)
 		xGov = "v1"
 	}
+	var votePolicy watcher.VotePolicy
+	if expectedVotes != "" {
+		votePolicy, err = watcher.LoadVotePolicy(expectedVotes)
+		if err != nil {
+			return err
+		}
+		log.Info().Msgf("loaded expected votes for %d proposals", len(votePolicy))
+	}
 	if !noGov {
-		votesWatcher := watcher.NewVotesWatcher(trackedValidators, metrics, pool, watcher.VotesWatcherOptions{
+		votesWatcher := watcher.NewVotesWatcher(trackedValidators, metrics, pool, wh, watcher.VotesWatcherOptions{
 			GovModuleVersion: xGov,
+			ExpectedVotes:    votePolicy,
 		})
+		api.VotesWatcher = votesWatcher
+		healthChecks = append(healthChecks, votesWatcher.Health())
 		errg.Go(func() error {
 			return votesWatcher.Start(ctx)
 		})
@@ -204,10 +338,14 @@ func RunFunc(cCtx *cli.Context) error {
 
 	var upgradeWatcher *watcher.UpgradeWatcher
 	if !noUpgrade {
-		upgradeWatcher = watcher.NewUpgradeWatcher(metrics, pool, wh, watcher.UpgradeWatcherOptions{
+		upgradeWatcher = watcher.NewUpgradeWatcher(metrics, pool, wh, os.Stdout, watcher.UpgradeWatcherOptions{
 			CheckPendingProposals: !noGov,
 			GovModuleVersion:      xGov,
+			ReminderLeadTimes:     reminderLeadTimes,
 		})
+		upgradeWatcher.OnUpgradePlan(statusWatcher.OnUpgradePlan)
+		api.UpgradeWatcher = upgradeWatcher
+		healthChecks = append(healthChecks, upgradeWatcher.Health())
 		errg.Go(func() error {
 			return upgradeWatcher.Start(ctx)
 		})
@@ -218,9 +356,12 @@ func RunFunc(cCtx *cli.Context) error {
 	//
 	pool.OnNodeStart(blockWatcher.OnNodeStart)
 	pool.OnNodeStatus(statusWatcher.OnNodeStatus)
-	pool.OnNodeEvent(rpc.EventNewBlock, blockWatcher.OnNewBlock)
+	pool.OnNodeDivergence(statusWatcher.OnNodeDivergence)
+	pool.OnNodeEvent(rpc.EventNewBlock, blockWatcher.OnNodeBlock)
+	pool.OnNodeEvent(rpc.EventNewBlock, dispatch("block", blockWatcher.OnNewBlock))
+	pool.OnNodeEvent(rpc.EventNewBlock, dispatch("halt", haltWatcher.OnNewBlock))
 	if upgradeWatcher != nil {
-		pool.OnNodeEvent(rpc.EventNewBlock, upgradeWatcher.OnNewBlock)
+		pool.OnNodeEvent(rpc.EventNewBlock, dispatch("upgrade", upgradeWatcher.OnNewBlock))
 	}
 
 	//
@@ -234,15 +375,33 @@ func RunFunc(cCtx *cli.Context) error {
 	// HTTP server
 	//
 	log.Info().Msgf("starting HTTP server on %s", httpAddr)
+	readyChecks := make([]*watcher.Health, len(readyWatchers))
+	for i, name := range readyWatchers {
+		if readyChecks[i] = healthChecks.Get(name); readyChecks[i] == nil {
+			return fmt.Errorf("unknown or disabled watcher required for readiness: %s", name)
+		}
+	}
 	readyProbe := func() bool {
-		// ready when at least one watcher is synced
-		return pool.GetSyncedNode() != nil
+		// ready when at least one watcher is synced (and the required watchers are healthy)
+		if pool.GetSyncedNode() == nil {
+			return false
+		}
+		for _, health := range readyChecks {
+			if !health.IsHealthy() {
+				return false
+			}
+		}
+		return true
 	}
 	httpServer := NewHTTPServer(
 		httpAddr,
 		WithReadyProbe(readyProbe),
 		WithLiveProbe(upProbe),
 		WithMetrics(metrics.Registry),
+		WithHealth(healthChecks),
+		WithAPI(api),
+		WithStream(hub),
+		WithDashboard(),
 	)
 	errg.Go(func() error {
 		return httpServer.Run()
@@ -263,6 +422,7 @@ func RunFunc(cCtx *cli.Context) error {
 	if err := pool.Stop(ctx); err != nil {
 		log.Error().Err(fmt.Errorf("failed to stop node pool: %w", err)).Msg("")
 	}
+	hub.Close()
 	if err := httpServer.Shutdown(ctx); err != nil {
 		log.Error().Err(fmt.Errorf("failed to stop http server: %w", err)).Msg("")
 	}
@@ -286,21 +446,66 @@ func logLevelFromString(level string) zerolog.Level {
 	}
 }
 
-func createNodePool(ctx context.Context, nodes []string) (*rpc.Pool, error) {
+type nodePoolOptions struct {
+	MaxLag           int64
+	RateLimit        float64
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	BackfillDepth       int64
+	BackfillConcurrency int
+	StartHeight         int64
+
+	QueryCacheTTL time.Duration // 0 to disable
+}
+
+func createNodePool(ctx context.Context, metrics *metrics.Metrics, nodes []string, chainID string, options nodePoolOptions) (*rpc.Pool, error) {
 	rpcNodes := make([]*rpc.Node, len(nodes))
+
+	// Module queries are cached across all the nodes
+	var queryCache *rpc.QueryCache
+	if options.QueryCacheTTL > 0 {
+		queryCache = rpc.NewQueryCache(options.QueryCacheTTL, metrics)
+	}
+
 	for i, endpoint := range nodes {
-		client, err := http.New(endpoint, "/websocket")
+		// Per-node options are set in the endpoint query string (eg. __websocket=0)
+		endpoint, clientOpts, err := rpc.ParseEndpoint(endpoint)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create client: %w", err)
+			return nil, fmt.Errorf("invalid node endpoint: %w", err)
+		}
+		clientOpts.Metrics = metrics
+		if clientOpts.RateLimit == 0 {
+			clientOpts.RateLimit = options.RateLimit
+		}
+
+		opts := []rpc.NodeOption{
+			rpc.BackfillDepth(options.BackfillDepth),
+			rpc.BackfillConcurrency(options.BackfillConcurrency),
+			rpc.StartHeight(options.StartHeight),
+		}
+		if queryCache != nil {
+			opts = append(opts, rpc.WithQueryCache(queryCache))
+		}
+		if options.BreakerThreshold > 0 {
+			clientOpts.Breaker = rpc.NewCircuitBreaker(options.BreakerThreshold, options.BreakerCooldown)
+			opts = append(opts, rpc.WithCircuitBreaker(clientOpts.Breaker))
 		}
 
-		opts := []rpc.NodeOption{}
+		client, err := rpc.NewClient(endpoint, clientOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client: %w", err)
+		}
 
-		// Check is query string websocket is present in the endpoint
-		if u, err := url.Parse(endpoint); err == nil {
-			if u.Query().Get("__websocket") == "0" {
-				opts = append(opts, rpc.DisableWebsocket())
+		if clientOpts.DisableWebsocket {
+			opts = append(opts, rpc.DisableWebsocket())
+		} else if clientOpts.HasTransportOptions() {
+			// The CometBFT websocket client does not support custom transports
+			events, err := rpc.NewWSEvents(endpoint, clientOpts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create websocket client: %w", err)
 			}
+			opts = append(opts, rpc.WithEventsClient(events))
 		}
 
 		rpcNodes[i] = rpc.NewNode(client, opts...)
@@ -323,23 +528,35 @@ func createNodePool(ctx context.Context, nodes []string) (*rpc.Pool, error) {
 		}
 	}
 
-	var rpcNode *rpc.Node
-	var chainID string
-	for _, node := range rpcNodes {
-		if chainID == "" {
-			chainID = node.ChainID()
-		} else if chainID != node.ChainID() && node.ChainID() != "" {
-			return nil, fmt.Errorf("nodes are on different chains: %s != %s", chainID, node.ChainID())
-		}
-		if node.IsSynced() {
-			rpcNode = node
-		}
+	// Use the network of most nodes when the chain ID is not specified
+	if chainID == "" {
+		chainID = majorityChainID(rpcNodes)
 	}
-	if rpcNode == nil {
+
+	// Nodes on another network are quarantined by the pool
+	pool := rpc.NewPool(chainID, rpcNodes, rpc.MaxLag(options.MaxLag))
+	if pool.GetSyncedNode() == nil {
 		return nil, fmt.Errorf("no nodes synced")
 	}
 
-	return rpc.NewPool(chainID, rpcNodes), nil
+	return pool, nil
+}
+
+func majorityChainID(nodes []*rpc.Node) string {
+	var (
+		chainID string
+		counts  = make(map[string]int)
+	)
+	for _, node := range nodes {
+		if node.ChainID() == "" {
+			continue
+		}
+		counts[node.ChainID()]++
+		if chainID == "" || counts[node.ChainID()] > counts[chainID] {
+			chainID = node.ChainID()
+		}
+	}
+	return chainID
 }
 
 func detectCosmosModules(ctx context.Context, node *rpc.Node) ([]*upgrade.ModuleVersion, error) {
@@ -347,7 +564,7 @@ func detectCosmosModules(ctx context.Context, node *rpc.Node) ([]*upgrade.Module
 		return nil, fmt.Errorf("no node available")
 	}
 
-	clientCtx := (client.Context{}).WithClient(node.Client)
+	clientCtx := (client.Context{}).WithClient(node.QueryClient())
 	queryClient := upgrade.NewQueryClient(clientCtx)
 	resp, err := queryClient.ModuleVersions(ctx, &upgrade.QueryModuleVersionsRequest{})
 	if err != nil {
@@ -376,7 +593,7 @@ func createTrackedValidators(ctx context.Context, pool *rpc.Pool, validators []s
 	var stakingValidators []staking.Validator
 	if !noStaking {
 		node := pool.GetSyncedNode()
-		clientCtx := (client.Context{}).WithClient(node.Client)
+		clientCtx := (client.Context{}).WithClient(node.QueryClient())
 		queryClient := staking.NewQueryClient(clientCtx)
 
 		resp, err := queryClient.Validators(ctx, &staking.QueryValidatorsRequest{
@@ -0,0 +1,23 @@
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// Static single-page dashboard, rendered in the browser from the JSON API
+// and the live block stream.
+//
+//go:embed static
+var static embed.FS
+
+// Handler serves the dashboard assets.
+func Handler() http.Handler {
+	assets, err := fs.Sub(static, "static")
+	if err != nil {
+		// Only fails if the embed directive is broken
+		panic(err)
+	}
+	return http.FileServerFS(assets)
+}
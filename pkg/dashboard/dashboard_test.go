@@ -0,0 +1,48 @@
+package dashboard
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestHandler(t *testing.T) {
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	get := func(t *testing.T, path string) (*http.Response, string) {
+		resp, err := server.Client().Get(server.URL + path)
+		assert.NilError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		assert.NilError(t, err)
+		return resp, string(body)
+	}
+
+	t.Run("Serve Index", func(t *testing.T) {
+		resp, body := get(t, "/")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Assert(t, strings.Contains(body, `<script src="app.js">`))
+	})
+
+	t.Run("Serve Assets", func(t *testing.T) {
+		resp, _ := get(t, "/app.js")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Assert(t, strings.Contains(resp.Header.Get("Content-Type"), "javascript"))
+
+		resp, _ = get(t, "/style.css")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Assert(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/css"))
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		resp, _ := get(t, "/missing.js")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,236 @@
+'use strict';
+
+const MAX_BLOCKS = 100;
+const REFRESH_INTERVAL = 30 * 1000;
+
+let validators = [];
+let blocks = []; // most recent first, as returned by the API
+
+async function fetchJSON(path) {
+  const resp = await fetch(path);
+  if (resp.status === 404) {
+    return null; // module disabled
+  }
+  if (!resp.ok) {
+    throw new Error(`${path}: ${resp.status}`);
+  }
+  return resp.json();
+}
+
+function el(tag, attrs = {}, ...children) {
+  const node = document.createElement(tag);
+  for (const [key, value] of Object.entries(attrs)) {
+    if (key === 'class') {
+      node.className = value;
+    } else {
+      node.setAttribute(key, value);
+    }
+  }
+  for (const child of children) {
+    node.append(child);
+  }
+  return node;
+}
+
+function badge(text, level) {
+  return el('span', { class: `badge ${level}` }, text);
+}
+
+function formatTokens(tokens) {
+  return Math.round(tokens).toLocaleString();
+}
+
+function formatDate(value) {
+  return new Date(value).toLocaleString();
+}
+
+function renderHeatmap() {
+  const heatmap = document.getElementById('heatmap');
+  const ordered = blocks.slice().reverse(); // oldest on the left
+
+  heatmap.replaceChildren(...validators.map((val) => {
+    const cells = ordered.map((block) => {
+      const outcome = (block.validators || []).find((v) => v.address === val.address);
+      let status = 'inactive';
+      if (outcome) {
+        status = outcome.solo ? 'solo' : outcome.outcome;
+      }
+      return el('span', { class: `cell ${status}`, title: `#${block.height} ${status}` });
+    });
+    return el('div', { class: 'row' }, el('span', { class: 'name', title: val.name }, val.name), el('div', { class: 'cells' }, ...cells));
+  }));
+
+  if (blocks.length > 0) {
+    document.getElementById('chain').textContent = `${blocks[0].chain_id} #${blocks[0].height}`;
+  }
+}
+
+function renderValidators() {
+  const tbody = document.querySelector('#validators tbody');
+  tbody.replaceChildren(...validators.map((val) => {
+    let status = badge('inactive', 'warn');
+    if (val.jailed) {
+      status = badge('jailed', 'ko');
+    } else if (val.bonded) {
+      status = badge('bonded', 'ok');
+    }
+    return el('tr', {},
+      el('td', { title: val.address }, val.moniker || val.name),
+      el('td', {}, val.rank ? `#${val.rank}` : '-'),
+      el('td', {}, formatTokens(val.tokens)),
+      el('td', {}, status),
+      el('td', {}, String(val.missed_blocks_window)));
+  }));
+}
+
+function renderProposals(proposals) {
+  const section = document.getElementById('proposals');
+  section.hidden = proposals === null;
+  if (proposals === null) {
+    return;
+  }
+
+  const tbody = section.querySelector('tbody');
+  if (proposals.length === 0) {
+    tbody.replaceChildren(el('tr', {}, el('td', { colspan: 3, class: 'muted' }, 'No proposal in voting period')));
+    return;
+  }
+
+  tbody.replaceChildren(...proposals.map((proposal) => {
+    const votes = proposal.votes.map((vote) => {
+      let level = vote.voted ? 'ok' : 'ko';
+      if (vote.voted && vote.expected && vote.option !== vote.expected) {
+        level = 'warn';
+      }
+      return badge(`${vote.name}: ${vote.voted ? vote.option : 'not voted'}`, level);
+    });
+    return el('tr', {},
+      el('td', {}, `#${proposal.id}`),
+      el('td', {}, formatDate(proposal.voting_end_time)),
+      el('td', {}, ...votes));
+  }));
+}
+
+function renderUpgrade(upgrade, enabled) {
+  const section = document.getElementById('upgrade');
+  section.hidden = !enabled;
+
+  const content = section.querySelector('.content');
+  if (!upgrade) {
+    content.replaceChildren(el('span', { class: 'muted' }, 'No upgrade planned'));
+    return;
+  }
+
+  const eta = upgrade.eta ? ` (estimated ${formatDate(upgrade.eta)})` : '';
+  content.replaceChildren(el('strong', {}, upgrade.name), ` at height ${upgrade.height}${eta}`);
+}
+
+function renderBabylon(babylon) {
+  const section = document.getElementById('babylon');
+  section.hidden = babylon === null;
+  if (babylon === null) {
+    return;
+  }
+
+  const content = section.querySelector('.content');
+  const children = [];
+
+  if (babylon.finality) {
+    children.push(el('p', {}, `Finality at #${babylon.finality.height} (${babylon.finality.providers} providers voted)`));
+    children.push(el('p', {}, ...babylon.finality.votes.map((vote) => badge(vote.name, vote.voted ? 'ok' : 'ko'))));
+  } else if (babylon.finality_providers && babylon.finality_providers.length > 0) {
+    children.push(el('p', { class: 'muted' }, 'Waiting for finality votes'));
+  }
+
+  if (babylon.checkpoint) {
+    const checkpoint = babylon.checkpoint;
+    children.push(el('p', {}, `Checkpoint of epoch ${checkpoint.epoch} (${checkpoint.voted_validators}/${checkpoint.total_validators} validators)`));
+    children.push(el('p', {}, ...checkpoint.votes.map((vote) => badge(vote.name, vote.voted ? 'ok' : 'ko'))));
+  }
+
+  content.replaceChildren(...children);
+}
+
+function renderNodes(nodes) {
+  const tbody = document.querySelector('#nodes tbody');
+  tbody.replaceChildren(...nodes.map((node) => {
+    let status = badge('synced', 'ok');
+    if (node.quarantine && node.quarantine.length > 0) {
+      status = badge(`quarantined (${node.quarantine.join(', ')})`, 'ko');
+    } else if (node.circuit_open) {
+      status = badge('circuit open', 'ko');
+    } else if (!node.synced) {
+      status = badge('syncing', 'warn');
+    } else if (node.lagging) {
+      status = badge('lagging', 'warn');
+    }
+    return el('tr', {},
+      el('td', {}, node.endpoint),
+      el('td', {}, String(node.height)),
+      el('td', {}, String(node.lag)),
+      el('td', {}, status));
+  }));
+}
+
+async function refresh() {
+  try {
+    const [vals, proposals, upgrade, babylon, nodes] = await Promise.all([
+      fetchJSON('api/v1/validators'),
+      fetchJSON('api/v1/proposals'),
+      fetch('api/v1/upgrade').then((resp) => (resp.ok ? resp.json().then((plan) => ({ plan })) : null)),
+      fetchJSON('api/v1/babylon'),
+      fetchJSON('api/v1/nodes'),
+    ]);
+
+    validators = vals || [];
+    renderValidators();
+    renderHeatmap();
+    renderProposals(proposals);
+    renderUpgrade(upgrade && upgrade.plan, upgrade !== null);
+    renderBabylon(babylon);
+    renderNodes(nodes || []);
+  } catch (err) {
+    console.error('failed to refresh dashboard', err);
+  }
+}
+
+async function loadBlocks() {
+  try {
+    blocks = (await fetchJSON(`api/v1/blocks?limit=${MAX_BLOCKS}`)) || [];
+    renderHeatmap();
+  } catch (err) {
+    console.error('failed to load blocks', err);
+  }
+}
+
+function connectStream() {
+  const status = document.getElementById('stream');
+  const source = new EventSource('api/v1/stream?types=block,babylon_finality,babylon_checkpoint');
+
+  source.onopen = () => {
+    status.textContent = 'live';
+    status.className = 'badge ok';
+  };
+  source.onerror = () => {
+    // The browser reconnects automatically
+    status.textContent = 'offline';
+    status.className = 'badge ko';
+  };
+
+  source.addEventListener('block', (msg) => {
+    const record = JSON.parse(msg.data).data;
+    if (blocks.length > 0 && record.height <= blocks[0].height) {
+      return;
+    }
+    blocks.unshift(record);
+    blocks = blocks.slice(0, MAX_BLOCKS);
+    renderHeatmap();
+  });
+
+  const refreshBabylon = () => fetchJSON('api/v1/babylon').then(renderBabylon).catch(() => {});
+  source.addEventListener('babylon_finality', refreshBabylon);
+  source.addEventListener('babylon_checkpoint', refreshBabylon);
+}
+
+refresh().then(loadBlocks).then(connectStream);
+setInterval(refresh, REFRESH_INTERVAL);
@@ -0,0 +1,91 @@
+package history
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+const (
+	FormatTable = "table"
+	FormatCSV   = "csv"
+	FormatJSON  = "json"
+)
+
+var reportColumns = []string{"validator", "address", "blocks", "validated", "uptime", "missed", "solo_missed", "proposed", "empty"}
+
+// Write renders the summary as a table, CSV or JSON.
+func (s *Summary) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatTable:
+		return s.writeTable(w)
+	case FormatCSV:
+		return s.writeCSV(w)
+	case FormatJSON:
+		return s.writeJSON(w)
+	default:
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+}
+
+func (s *Summary) writeTable(w io.Writer) error {
+	fmt.Fprintf(w, "Heights %d to %d (%d blocks, %d transactions)\n\n", s.FromHeight, s.ToHeight, s.Blocks, s.Transactions)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VALIDATOR\tBLOCKS\tVALIDATED\tUPTIME\tMISSED\tSOLO MISSED\tPROPOSED\tEMPTY")
+	for _, val := range s.Validators {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f%%\t%d\t%d\t%d\t%d\n",
+			val.Name, val.Blocks, val.Validated, val.Uptime()*100, val.Missed, val.SoloMissed, val.Proposed, val.Empty)
+	}
+	return tw.Flush()
+}
+
+func (s *Summary) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(reportColumns); err != nil {
+		return err
+	}
+	for _, val := range s.Validators {
+		err := cw.Write([]string{
+			val.Name,
+			val.Address,
+			strconv.Itoa(val.Blocks),
+			strconv.Itoa(val.Validated),
+			strconv.FormatFloat(val.Uptime(), 'f', 6, 64),
+			strconv.Itoa(val.Missed),
+			strconv.Itoa(val.SoloMissed),
+			strconv.Itoa(val.Proposed),
+			strconv.Itoa(val.Empty),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *Summary) writeJSON(w io.Writer) error {
+	type validatorReport struct {
+		*ValidatorSummary
+		Uptime float64 `json:"uptime"`
+	}
+
+	validators := make([]validatorReport, len(s.Validators))
+	for i, val := range s.Validators {
+		validators[i] = validatorReport{ValidatorSummary: val, Uptime: val.Uptime()}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		FromHeight   int64             `json:"from_height"`
+		ToHeight     int64             `json:"to_height"`
+		Blocks       int               `json:"blocks"`
+		Transactions int               `json:"transactions"`
+		Validators   []validatorReport `json:"validators"`
+	}{s.FromHeight, s.ToHeight, s.Blocks, s.Transactions, validators})
+}
@@ -0,0 +1,48 @@
+package history
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSummaryWrite(t *testing.T) {
+	summary := NewSummary()
+	summary.Add(BlockRecord{Height: 10, Transactions: 3, Validators: []ValidatorOutcome{{Address: "AAAA", Name: "Kiln", Outcome: OutcomeProposed}}})
+	summary.Add(BlockRecord{Height: 11, Transactions: 0, Validators: []ValidatorOutcome{{Address: "AAAA", Name: "Kiln", Outcome: OutcomeSigned}}})
+	summary.Add(BlockRecord{Height: 12, Transactions: 1, Validators: []ValidatorOutcome{{Address: "AAAA", Name: "Kiln", Outcome: OutcomeAbsent, Solo: true}}})
+	summary.Add(BlockRecord{Height: 13, Transactions: 0, Validators: []ValidatorOutcome{{Address: "AAAA", Name: "Kiln", Outcome: OutcomeSigned}}})
+
+	t.Run("Table", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NilError(t, summary.Write(&buf, FormatTable))
+		assert.Equal(t, strings.Join([]string{
+			"Heights 10 to 13 (4 blocks, 4 transactions)",
+			"",
+			"VALIDATOR  BLOCKS  VALIDATED  UPTIME  MISSED  SOLO MISSED  PROPOSED  EMPTY",
+			"Kiln       4       3          75.00%  1       1            1         0",
+		}, "\n")+"\n", buf.String())
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NilError(t, summary.Write(&buf, FormatCSV))
+		assert.Equal(t, strings.Join([]string{
+			"validator,address,blocks,validated,uptime,missed,solo_missed,proposed,empty",
+			"Kiln,AAAA,4,3,0.750000,1,1,1,0",
+		}, "\n")+"\n", buf.String())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NilError(t, summary.Write(&buf, FormatJSON))
+		assert.Assert(t, strings.Contains(buf.String(), `"uptime": 0.75`))
+		assert.Assert(t, strings.Contains(buf.String(), `"solo_missed": 1`))
+	})
+
+	t.Run("Unknown Format", func(t *testing.T) {
+		assert.ErrorContains(t, summary.Write(&bytes.Buffer{}, "xml"), "unknown report format")
+	})
+}
@@ -0,0 +1,192 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+type Outcome string
+
+const (
+	OutcomeProposed Outcome = "proposed" // proposed a block with transactions
+	OutcomeEmpty    Outcome = "empty"    // proposed a block without transactions
+	OutcomeSigned   Outcome = "signed"
+	OutcomeNil      Outcome = "nil" // voted nil (counted as signed)
+	OutcomeAbsent   Outcome = "absent"
+)
+
+// BlockRecord is the signing result of a processed height.
+type BlockRecord struct {
+	ChainID      string             `json:"chain_id"`
+	Height       int64              `json:"height"`
+	Time         time.Time          `json:"time"`
+	Proposer     string             `json:"proposer"`
+	Transactions int                `json:"transactions"`
+	SignedRatio  float64            `json:"signed_ratio"`
+	Validators   []ValidatorOutcome `json:"validators"`
+}
+
+// ValidatorOutcome is the result of a tracked validator for a height
+// (validators out of the active set are not recorded).
+type ValidatorOutcome struct {
+	Address string  `json:"address"`
+	Name    string  `json:"name"`
+	Outcome Outcome `json:"outcome"`
+	Solo    bool    `json:"solo,omitempty"` // missed while most validators signed
+}
+
+// Outcome returns the outcome of a validator (false if not in the active set).
+func (r BlockRecord) Outcome(address string) (ValidatorOutcome, bool) {
+	for _, outcome := range r.Validators {
+		if outcome.Address == address {
+			return outcome, true
+		}
+	}
+	return ValidatorOutcome{}, false
+}
+
+// Store is an embedded disk-backed store of the signing history of a chain.
+type Store struct {
+	db        *leveldb.DB
+	chainID   string
+	retention time.Duration
+}
+
+func Open(path string, chainID string, retention time.Duration) (*Store, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history store: %w", err)
+	}
+
+	return &Store{
+		db:        db,
+		chainID:   chainID,
+		retention: retention,
+	}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Start prunes the records older than the retention periodically.
+func (s *Store) Start(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		pruned, err := s.Prune(time.Now().Add(-s.retention))
+		if err != nil {
+			log.Error().Err(err).Msg("failed to prune history store")
+		} else if pruned > 0 {
+			log.Debug().Int("records", pruned).Msg("pruned history store")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Save records the result of a height (replacing any previous record).
+func (s *Store) Save(record BlockRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	return s.db.Put(s.key(record.Height), data, nil)
+}
+
+// Latest returns the record of the highest height (nil if the store is empty).
+func (s *Store) Latest() (*BlockRecord, error) {
+	iter := s.db.NewIterator(util.BytesPrefix(s.prefix()), nil)
+	defer iter.Release()
+
+	if !iter.Last() {
+		return nil, iter.Error()
+	}
+
+	var record BlockRecord
+	if err := json.Unmarshal(iter.Value(), &record); err != nil {
+		return nil, fmt.Errorf("failed to decode record: %w", err)
+	}
+
+	return &record, nil
+}
+
+// Range calls fn on each record between the given heights (inclusive), in
+// height order. A zero height means no bound.
+func (s *Store) Range(from, to int64, fn func(BlockRecord) error) error {
+	keys := util.BytesPrefix(s.prefix())
+	if from > 0 {
+		keys.Start = s.key(from)
+	}
+	if to > 0 {
+		keys.Limit = s.key(to + 1)
+	}
+
+	iter := s.db.NewIterator(keys, nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		var record BlockRecord
+		if err := json.Unmarshal(iter.Value(), &record); err != nil {
+			return fmt.Errorf("failed to decode record: %w", err)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+
+	return iter.Error()
+}
+
+// Prune deletes the records of the blocks older than the given time.
+func (s *Store) Prune(before time.Time) (int, error) {
+	iter := s.db.NewIterator(util.BytesPrefix(s.prefix()), nil)
+	defer iter.Release()
+
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		var record BlockRecord
+		if err := json.Unmarshal(iter.Value(), &record); err != nil {
+			return 0, fmt.Errorf("failed to decode record: %w", err)
+		}
+		// Records are sorted by height, stop at the first recent block
+		if !record.Time.Before(before) {
+			break
+		}
+		batch.Delete(append([]byte{}, iter.Key()...))
+	}
+	if err := iter.Error(); err != nil {
+		return 0, err
+	}
+
+	if err := s.db.Write(batch, nil); err != nil {
+		return 0, fmt.Errorf("failed to prune records: %w", err)
+	}
+
+	return batch.Len(), nil
+}
+
+func (s *Store) prefix() []byte {
+	return []byte("block/" + s.chainID + "/")
+}
+
+// key is sortable by height (zero-padded)
+func (s *Store) key(height int64) []byte {
+	return []byte(fmt.Sprintf("block/%s/%020d", s.chainID, height))
+}
@@ -0,0 +1,105 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestStore(t *testing.T) {
+	var (
+		chainID = "chain-42"
+		kiln    = "3DC4DD610817606AD4A8F9D762A068A81E8741E2"
+		now     = time.Now()
+	)
+
+	store, err := Open(t.TempDir(), chainID, time.Hour)
+	assert.NilError(t, err)
+	defer store.Close()
+
+	t.Run("Empty Store", func(t *testing.T) {
+		latest, err := store.Latest()
+		assert.NilError(t, err)
+		assert.Assert(t, latest == nil)
+	})
+
+	outcomes := []Outcome{OutcomeSigned, OutcomeAbsent, OutcomeAbsent, OutcomeProposed, OutcomeEmpty, OutcomeNil, OutcomeAbsent}
+	for i, outcome := range outcomes {
+		height := int64(98 + i)
+		err := store.Save(BlockRecord{
+			ChainID:      chainID,
+			Height:       height,
+			Time:         now.Add(time.Duration(i-len(outcomes)) * 20 * time.Minute),
+			Transactions: i,
+			SignedRatio:  0.9,
+			Validators: []ValidatorOutcome{
+				{Address: kiln, Name: "Kiln", Outcome: outcome, Solo: outcome == OutcomeAbsent && i > 1},
+			},
+		})
+		assert.NilError(t, err)
+	}
+
+	t.Run("Latest", func(t *testing.T) {
+		latest, err := store.Latest()
+		assert.NilError(t, err)
+		assert.Equal(t, int64(104), latest.Height)
+		assert.Equal(t, OutcomeAbsent, latest.Validators[0].Outcome)
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		heights := []int64{}
+		err := store.Range(99, 101, func(record BlockRecord) error {
+			heights = append(heights, record.Height)
+			return nil
+		})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []int64{99, 100, 101}, heights)
+	})
+
+	t.Run("Summary", func(t *testing.T) {
+		summary := NewSummary()
+		err := store.Range(0, 0, func(record BlockRecord) error {
+			summary.Add(record)
+			return nil
+		})
+		assert.NilError(t, err)
+
+		assert.Equal(t, int64(98), summary.FromHeight)
+		assert.Equal(t, int64(104), summary.ToHeight)
+		assert.Equal(t, 7, summary.Blocks)
+		assert.Equal(t, 21, summary.Transactions)
+
+		val := summary.Validator(kiln, "Kiln")
+		assert.Equal(t, 7, val.Blocks)
+		assert.Equal(t, 4, val.Validated)
+		assert.Equal(t, 3, val.Missed)
+		assert.Equal(t, 2, val.SoloMissed)
+		assert.Equal(t, 2, val.Proposed)
+		assert.Equal(t, 1, val.Empty)
+		assert.Equal(t, 1, val.ConsecutiveMissed)
+	})
+
+	t.Run("Prune", func(t *testing.T) {
+		pruned, err := store.Prune(now.Add(-time.Hour))
+		assert.NilError(t, err)
+		assert.Equal(t, 4, pruned)
+
+		heights := []int64{}
+		err = store.Range(0, 0, func(record BlockRecord) error {
+			heights = append(heights, record.Height)
+			return nil
+		})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []int64{102, 103, 104}, heights)
+	})
+
+	t.Run("Other Chain", func(t *testing.T) {
+		store.chainID = "chain-43"
+		defer func() { store.chainID = chainID }()
+
+		latest, err := store.Latest()
+		assert.NilError(t, err)
+		assert.Assert(t, latest == nil)
+	})
+}
@@ -0,0 +1,84 @@
+package history
+
+// ValidatorSummary aggregates the outcomes of a validator over several heights.
+type ValidatorSummary struct {
+	Address           string `json:"address"`
+	Name              string `json:"name"`
+	Blocks            int    `json:"blocks"` // heights in the active set
+	Validated         int    `json:"validated"`
+	Missed            int    `json:"missed"`
+	SoloMissed        int    `json:"solo_missed"`
+	Proposed          int    `json:"proposed"`
+	Empty             int    `json:"empty"`
+	ConsecutiveMissed int    `json:"consecutive_missed"`
+}
+
+// Uptime returns the ratio of validated blocks while in the active set.
+func (s ValidatorSummary) Uptime() float64 {
+	if s.Blocks == 0 {
+		return 0
+	}
+	return float64(s.Validated) / float64(s.Blocks)
+}
+
+// Summary aggregates the records of a height range.
+type Summary struct {
+	FromHeight   int64               `json:"from_height"`
+	ToHeight     int64               `json:"to_height"`
+	Blocks       int                 `json:"blocks"`
+	Transactions int                 `json:"transactions"`
+	Validators   []*ValidatorSummary `json:"validators"`
+}
+
+func NewSummary() *Summary {
+	return &Summary{}
+}
+
+// Add aggregates a record (records are expected in height order).
+func (s *Summary) Add(record BlockRecord) {
+	if s.Blocks == 0 || record.Height < s.FromHeight {
+		s.FromHeight = record.Height
+	}
+	if record.Height > s.ToHeight {
+		s.ToHeight = record.Height
+	}
+	s.Blocks++
+	s.Transactions += record.Transactions
+
+	for _, outcome := range record.Validators {
+		val := s.Validator(outcome.Address, outcome.Name)
+		val.Blocks++
+
+		switch outcome.Outcome {
+		case OutcomeProposed, OutcomeEmpty:
+			val.Validated++
+			val.Proposed++
+			val.ConsecutiveMissed = 0
+			if outcome.Outcome == OutcomeEmpty {
+				val.Empty++
+			}
+		case OutcomeSigned, OutcomeNil:
+			val.Validated++
+			val.ConsecutiveMissed = 0
+		case OutcomeAbsent:
+			val.Missed++
+			val.ConsecutiveMissed++
+			if outcome.Solo {
+				val.SoloMissed++
+			}
+		}
+	}
+}
+
+// Validator returns the summary of a validator (created if unknown).
+func (s *Summary) Validator(address string, name string) *ValidatorSummary {
+	for _, val := range s.Validators {
+		if val.Address == address {
+			return val
+		}
+	}
+
+	val := &ValidatorSummary{Address: address, Name: name}
+	s.Validators = append(s.Validators, val)
+	return val
+}
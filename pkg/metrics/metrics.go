@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 )
@@ -17,6 +19,13 @@ type Metrics struct {
 	TrackedBlocks            *prometheus.CounterVec
 	Transactions             *prometheus.CounterVec
 	UpgradePlan              *prometheus.GaugeVec
+	UpgradeETA               *prometheus.GaugeVec
+	UpgradeProposal          *prometheus.GaugeVec
+	UpgradeBinary            *prometheus.GaugeVec
+	UpgradeApplied           *prometheus.GaugeVec
+	ChainHalted              *prometheus.GaugeVec
+	ChainDowntime            *prometheus.GaugeVec
+	TimeSinceLatestBlock     *prometheus.GaugeVec
 	SignedBlocksWindow       *prometheus.GaugeVec
 	MinSignedBlocksPerWindow *prometheus.GaugeVec
 	DowntimeJailDuration     *prometheus.GaugeVec
@@ -37,6 +46,11 @@ type Metrics struct {
 	IsJailed                *prometheus.GaugeVec
 	Commission              *prometheus.GaugeVec
 	Vote                    *prometheus.GaugeVec
+	VotePolicyMatch         *prometheus.GaugeVec
+
+	// Validator nodes metrics
+	ValidatorPeerConnected         *prometheus.GaugeVec
+	ValidatorPeerConnectedDuration *prometheus.GaugeVec
 
 	// Babylon metrics
 	BabylonEpoch                           *prometheus.GaugeVec
@@ -50,11 +64,41 @@ type Metrics struct {
 	BabylonConsecutiveMissedFinalityVotes  *prometheus.GaugeVec
 
 	// Node metrics
-	NodeBlockHeight *prometheus.GaugeVec
-	NodeSynced      *prometheus.GaugeVec
+	NodeBlockHeight     *prometheus.GaugeVec
+	NodeSynced          *prometheus.GaugeVec
+	NodeInfo            *prometheus.GaugeVec
+	NodeUpgradeOutdated *prometheus.GaugeVec
+	NodeUpgradeEarly    *prometheus.GaugeVec
+	NodePeers           *prometheus.GaugeVec
+	NodeMempoolTxs      *prometheus.GaugeVec
+	NodeMempoolBytes    *prometheus.GaugeVec
+	NodeClockDrift      *prometheus.GaugeVec
+	NodeQuarantined     *prometheus.GaugeVec
+	NodeDivergence      *prometheus.GaugeVec
+	NodeLag             *prometheus.GaugeVec
+
+	// RPC metrics
+	RPCRequestDuration  *prometheus.HistogramVec
+	RPCRequestErrors    *prometheus.CounterVec
+	RPCRequestsInFlight *prometheus.GaugeVec
+	RPCCircuitBreaker   *prometheus.GaugeVec
+	QueryCacheHits      *prometheus.CounterVec
+	QueryCacheMisses    *prometheus.CounterVec
+
+	// Event dispatch metrics
+	EventQueueDepth   *prometheus.GaugeVec
+	EventQueueDropped *prometheus.CounterVec
+
+	// Exporter metrics
+	BuildInfo                  *prometheus.GaugeVec
+	Uptime                     prometheus.GaugeFunc
+	WatcherLastProcessedTime   *prometheus.GaugeVec
+	WatcherLastProcessedHeight *prometheus.GaugeVec
 }
 
 func New(namespace string) *Metrics {
+	startTime := time.Now()
+
 	metrics := &Metrics{
 		Registry: prometheus.NewRegistry(),
 		BlockHeight: prometheus.NewGaugeVec(
@@ -209,6 +253,14 @@ func New(namespace string) *Metrics {
 			},
 			[]string{"chain_id", "address", "name", "proposal_id"},
 		),
+		VotePolicyMatch: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "vote_policy_match",
+				Help:      "Set to 1 if the validator vote matches the expected vote policy",
+			},
+			[]string{"chain_id", "address", "name", "proposal_id", "expected"},
+		),
 		NodeBlockHeight: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
@@ -225,6 +277,135 @@ func New(namespace string) *Metrics {
 			},
 			[]string{"chain_id", "node"},
 		),
+		ValidatorPeerConnected: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "validator_peer_connected",
+				Help:      "Set to 1 if the validator node (or sentry) is a connected peer of the node",
+			},
+			[]string{"chain_id", "address", "name", "node", "peer_id", "role"},
+		),
+		ValidatorPeerConnectedDuration: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "validator_peer_connected_seconds",
+				Help:      "Number of seconds since the validator node (or sentry) is connected to the node",
+			},
+			[]string{"chain_id", "address", "name", "node", "peer_id", "role"},
+		),
+		NodeInfo: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "node_info",
+				Help:      "Software versions reported by each node (always set to 1)",
+			},
+			[]string{"chain_id", "node", "version", "app_name", "app_version", "app_protocol"},
+		),
+		NodeUpgradeOutdated: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "node_upgrade_outdated",
+				Help:      "Set to 1 if the node still runs the old binary after the upgrade height",
+			},
+			[]string{"chain_id", "node", "upgrade"},
+		),
+		NodeUpgradeEarly: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "node_upgrade_early",
+				Help:      "Set to 1 if the node reports the upgrade version before the upgrade height",
+			},
+			[]string{"chain_id", "node", "upgrade"},
+		),
+		NodePeers: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "node_peers",
+				Help:      "Number of peers connected to each node (by direction)",
+			},
+			[]string{"chain_id", "node", "direction"},
+		),
+		NodeMempoolTxs: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "node_mempool_txs",
+				Help:      "Number of unconfirmed transactions in the mempool of each node",
+			},
+			[]string{"chain_id", "node"},
+		),
+		NodeMempoolBytes: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "node_mempool_bytes",
+				Help:      "Size in bytes of the unconfirmed transactions in the mempool of each node",
+			},
+			[]string{"chain_id", "node"},
+		),
+		NodeClockDrift: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "node_clock_drift_seconds",
+				Help:      "Difference in seconds between the local clock and the latest block time of each node",
+			},
+			[]string{"chain_id", "node"},
+		),
+		NodeQuarantined: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "node_quarantined",
+				Help:      "Set to 1 if the node is quarantined (ie. excluded from the pool) for the given reason",
+			},
+			[]string{"chain_id", "node", "reason"},
+		),
+		NodeDivergence: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "node_divergence",
+				Help:      "Height at which the node diverged from the other nodes of the pool (0 if not diverging)",
+			},
+			[]string{"chain_id", "node", "kind"},
+		),
+		NodeLag: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "node_lag_blocks",
+				Help:      "Number of blocks each node is behind the highest node of the pool",
+			},
+			[]string{"chain_id", "node"},
+		),
+		RPCRequestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "rpc_request_duration_seconds",
+				Help:      "Duration in seconds of the RPC requests sent to each node (by method or ABCI query path)",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"node", "method"},
+		),
+		RPCRequestErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "rpc_request_errors",
+				Help:      "Number of failed RPC requests sent to each node (by method or ABCI query path)",
+			},
+			[]string{"node", "method"},
+		),
+		RPCRequestsInFlight: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "rpc_requests_in_flight",
+				Help:      "Number of RPC requests currently sent to each node",
+			},
+			[]string{"node"},
+		),
+		RPCCircuitBreaker: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "rpc_circuit_breaker",
+				Help:      "Set to 1 for the current state of the circuit breaker of each node (closed, open or half_open)",
+			},
+			[]string{"node", "state"},
+		),
 		UpgradePlan: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
@@ -233,6 +414,62 @@ func New(namespace string) *Metrics {
 			},
 			[]string{"chain_id", "version", "block"},
 		),
+		UpgradeProposal: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "upgrade_proposal",
+				Help:      "Block height of each tracked upgrade (on-chain plan & pending proposals)",
+			},
+			[]string{"chain_id", "proposal_id", "status", "action", "version", "block"},
+		),
+		UpgradeBinary: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "upgrade_binary_info",
+				Help:      "Binaries advertised in the info of upgrade plans (always set to 1)",
+			},
+			[]string{"chain_id", "version", "block", "platform", "url", "checksum"},
+		),
+		UpgradeApplied: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "upgrade_applied",
+				Help:      "Set to 1 if the upgrade plan has been applied once its height is passed",
+			},
+			[]string{"chain_id", "version", "block"},
+		),
+		ChainHalted: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "chain_halted",
+				Help:      "Set to 1 if no block has been produced for longer than the halt threshold",
+			},
+			[]string{"chain_id"},
+		),
+		ChainDowntime: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "chain_downtime_seconds",
+				Help:      "Duration of the latest chain halt in seconds",
+			},
+			[]string{"chain_id"},
+		),
+		TimeSinceLatestBlock: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "time_since_latest_block_seconds",
+				Help:      "Number of seconds since the latest block (all nodes mixed up)",
+			},
+			[]string{"chain_id"},
+		),
+		UpgradeETA: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "upgrade_eta_seconds",
+				Help:      "Estimated number of seconds before the upcoming upgrade (based on recent block times)",
+			},
+			[]string{"chain_id", "version", "block"},
+		),
 		ProposalEndTime: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: namespace,
@@ -353,6 +590,70 @@ func New(namespace string) *Metrics {
 			},
 			[]string{"chain_id", "address", "name"},
 		),
+		QueryCacheHits: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "query_cache_hits",
+				Help:      "Number of module queries served from the cache (or collapsed with an identical in-flight query)",
+			},
+			[]string{"path"},
+		),
+		QueryCacheMisses: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "query_cache_misses",
+				Help:      "Number of module queries sent to the nodes",
+			},
+			[]string{"path"},
+		),
+		EventQueueDepth: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "event_queue_depth",
+				Help:      "Number of node events waiting to be processed by a watcher",
+			},
+			[]string{"watcher"},
+		),
+		EventQueueDropped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "event_queue_dropped",
+				Help:      "Number of node events dropped before reaching a watcher (duplicate height or full queue)",
+			},
+			[]string{"watcher", "reason"},
+		),
+		BuildInfo: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "build_info",
+				Help:      "Version of the exporter (always 1)",
+			},
+			[]string{"version", "go_version"},
+		),
+		Uptime: prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "uptime_seconds",
+				Help:      "Number of seconds since the exporter started",
+			},
+			func() float64 { return time.Since(startTime).Seconds() },
+		),
+		WatcherLastProcessedTime: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "watcher_last_processed_timestamp",
+				Help:      "Timestamp of the latest iteration of a watcher loop",
+			},
+			[]string{"watcher"},
+		),
+		WatcherLastProcessedHeight: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "watcher_last_processed_height",
+				Help:      "Latest block height processed by a watcher loop",
+			},
+			[]string{"watcher"},
+		),
 	}
 
 	return metrics
@@ -381,9 +682,33 @@ func (m *Metrics) Register() {
 	m.Registry.MustRegister(m.Commission)
 	m.Registry.MustRegister(m.IsJailed)
 	m.Registry.MustRegister(m.Vote)
+	m.Registry.MustRegister(m.VotePolicyMatch)
 	m.Registry.MustRegister(m.NodeBlockHeight)
 	m.Registry.MustRegister(m.NodeSynced)
+	m.Registry.MustRegister(m.NodeInfo)
+	m.Registry.MustRegister(m.ValidatorPeerConnected)
+	m.Registry.MustRegister(m.ValidatorPeerConnectedDuration)
+	m.Registry.MustRegister(m.NodeUpgradeOutdated)
+	m.Registry.MustRegister(m.NodeUpgradeEarly)
+	m.Registry.MustRegister(m.NodePeers)
+	m.Registry.MustRegister(m.NodeMempoolTxs)
+	m.Registry.MustRegister(m.NodeMempoolBytes)
+	m.Registry.MustRegister(m.NodeClockDrift)
+	m.Registry.MustRegister(m.NodeQuarantined)
+	m.Registry.MustRegister(m.NodeDivergence)
+	m.Registry.MustRegister(m.NodeLag)
+	m.Registry.MustRegister(m.RPCRequestDuration)
+	m.Registry.MustRegister(m.RPCRequestErrors)
+	m.Registry.MustRegister(m.RPCRequestsInFlight)
+	m.Registry.MustRegister(m.RPCCircuitBreaker)
 	m.Registry.MustRegister(m.UpgradePlan)
+	m.Registry.MustRegister(m.UpgradeETA)
+	m.Registry.MustRegister(m.UpgradeProposal)
+	m.Registry.MustRegister(m.UpgradeBinary)
+	m.Registry.MustRegister(m.UpgradeApplied)
+	m.Registry.MustRegister(m.ChainHalted)
+	m.Registry.MustRegister(m.ChainDowntime)
+	m.Registry.MustRegister(m.TimeSinceLatestBlock)
 	m.Registry.MustRegister(m.ProposalEndTime)
 	m.Registry.MustRegister(m.SignedBlocksWindow)
 	m.Registry.MustRegister(m.MinSignedBlocksPerWindow)
@@ -399,4 +724,12 @@ func (m *Metrics) Register() {
 	m.Registry.MustRegister(m.BabylonCommittedFinalityVotes)
 	m.Registry.MustRegister(m.BabylonMissedFinalityVotes)
 	m.Registry.MustRegister(m.BabylonConsecutiveMissedFinalityVotes)
+	m.Registry.MustRegister(m.QueryCacheHits)
+	m.Registry.MustRegister(m.QueryCacheMisses)
+	m.Registry.MustRegister(m.EventQueueDepth)
+	m.Registry.MustRegister(m.EventQueueDropped)
+	m.Registry.MustRegister(m.BuildInfo)
+	m.Registry.MustRegister(m.Uptime)
+	m.Registry.MustRegister(m.WatcherLastProcessedTime)
+	m.Registry.MustRegister(m.WatcherLastProcessedHeight)
 }
@@ -0,0 +1,83 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/cometbft/cometbft/types"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultBackfillDepth       = 20
+	defaultBackfillConcurrency = 4
+)
+
+// BackfillDepth sets the maximum number of missed blocks fetched when the
+// node falls behind (eg. after a websocket outage).
+func BackfillDepth(depth int64) NodeOption {
+	return func(n *Node) {
+		n.backfillDepth = depth
+	}
+}
+
+// BackfillConcurrency sets the number of blocks fetched in parallel.
+func BackfillConcurrency(concurrency int) NodeOption {
+	return func(n *Node) {
+		if concurrency > 0 {
+			n.backfillConcurrency = concurrency
+		}
+	}
+}
+
+// StartHeight fetches all the blocks since the given height on startup
+// (regardless of the backfill depth).
+func StartHeight(height int64) NodeOption {
+	return func(n *Node) {
+		n.startHeight = height
+	}
+}
+
+// FetchBlocks fetches the blocks in parallel and calls handle in height order.
+//
+// At most `backfillConcurrency` blocks are fetched or waiting to be handled at
+// the same time.
+func (n *Node) FetchBlocks(ctx context.Context, from, to int64, handle func(*types.Block)) {
+	var (
+		sem     = make(chan struct{}, n.backfillConcurrency)
+		results = make(chan chan *types.Block, n.backfillConcurrency)
+	)
+
+	go func() {
+		defer close(results)
+
+		for height := from; height <= to; height++ {
+			select {
+			case <-ctx.Done():
+				return
+			case sem <- struct{}{}:
+			}
+
+			result := make(chan *types.Block, 1)
+			results <- result
+
+			go func(height int64) {
+				blockResp, err := n.Client.Block(ctx, &height)
+				if err != nil {
+					log.Error().Err(err).Str("node", n.Redacted()).Int64("height", height).Msgf("failed to sync with latest block")
+					result <- nil
+					return
+				}
+				result <- blockResp.Block
+			}(height)
+		}
+	}()
+
+	for result := range results {
+		block := <-result
+		<-sem
+
+		if block != nil {
+			handle(block)
+		}
+	}
+}
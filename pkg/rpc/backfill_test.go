@@ -0,0 +1,103 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cometbft/cometbft/types"
+	"gotest.tools/assert"
+)
+
+func TestSyncBlocks(t *testing.T) {
+	const latestHeight = 100
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params struct {
+				Height string `json:"height"`
+			} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch req.Method {
+		case "status":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"node_info":{"network":"chain-42"},"sync_info":{"latest_block_height":"%d","latest_block_time":"%s","catching_up":false}}}`,
+				req.ID, latestHeight, time.Now().UTC().Format(time.RFC3339Nano))
+			return
+		case "abci_info":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"Method not found"}}`, req.ID)
+			return
+		}
+
+		height := int64(latestHeight)
+		if req.Params.Height != "" {
+			height, _ = strconv.ParseInt(req.Params.Height, 10, 64)
+			// Lower blocks are slower to fetch
+			time.Sleep(time.Duration(latestHeight-height) * time.Millisecond)
+		}
+
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"block_id":{"hash":"","parts":{"total":0,"hash":""}},"block":{"header":{"height":"%d"},"data":{"txs":[]},"evidence":{"evidence":[]},"last_commit":null}}}`, req.ID, height)
+	}))
+	defer server.Close()
+
+	syncBlocks := func(options ...NodeOption) []int64 {
+		client, err := NewClient(server.URL, ClientOptions{})
+		assert.NilError(t, err)
+
+		heights := []int64{}
+		node := NewNode(client, options...)
+		node.OnEvent(EventNewBlock, func(ctx context.Context, n *Node, event *ctypes.ResultEvent) error {
+			heights = append(heights, event.Data.(types.EventDataNewBlock).Block.Height)
+			return nil
+		})
+		node.syncBlocks(context.Background())
+
+		return heights
+	}
+
+	t.Run("Backfill Depth", func(t *testing.T) {
+		heights := syncBlocks(BackfillDepth(5), BackfillConcurrency(3))
+		assert.DeepEqual(t, []int64{96, 97, 98, 99, 100}, heights)
+	})
+
+	t.Run("Start Height", func(t *testing.T) {
+		heights := syncBlocks(BackfillDepth(5), BackfillConcurrency(4), StartHeight(80))
+		assert.Equal(t, 21, len(heights))
+		for i, height := range heights {
+			assert.Equal(t, int64(80+i), height)
+		}
+	})
+	t.Run("Backfill Longer Than Sync Window", func(t *testing.T) {
+		client, err := NewClient(server.URL, ClientOptions{})
+		assert.NilError(t, err)
+
+		// The status was synced before a backfill started minutes ago
+		node := NewNode(client, StartHeight(90))
+		status := &ctypes.ResultStatus{}
+		status.SyncInfo.LatestBlockTime = time.Now().Add(-3 * time.Minute)
+		node.status.Store(status)
+		node.statusTime.Store(time.Now().Add(-3 * time.Minute).UnixNano())
+
+		synced := []bool{}
+		node.OnEvent(EventNewBlock, func(ctx context.Context, n *Node, event *ctypes.ResultEvent) error {
+			synced = append(synced, n.IsSynced())
+			return nil
+		})
+		node.syncBlocks(context.Background())
+
+		assert.Equal(t, 11, len(synced))
+		for _, ok := range synced {
+			assert.Equal(t, true, ok)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package rpc
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// BreakerStates lists all the states of a circuit breaker.
+var BreakerStates = []string{BreakerClosed, BreakerOpen, BreakerHalfOpen}
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type OnBreakerStateChange func(state string)
+
+// CircuitBreaker takes a failing node out of use for a cooldown period.
+//
+// After the cooldown, a single probe request is allowed (half-open): the
+// breaker closes if it succeeds, or opens again if it fails.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int // consecutive failures
+	openedAt time.Time
+	probing  bool
+
+	onStateChange []OnBreakerStateChange
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+	}
+}
+
+// OnStateChange registers a callback called each time the breaker state changes.
+func (b *CircuitBreaker) OnStateChange(callback OnBreakerStateChange) {
+	b.onStateChange = append(b.onStateChange, callback)
+}
+
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports if a request can be sent to the node.
+func (b *CircuitBreaker) Allow(now time.Time) bool {
+	b.mu.Lock()
+
+	switch b.state {
+	case BreakerOpen:
+		if now.Sub(b.openedAt) < b.cooldown {
+			b.mu.Unlock()
+			return false
+		}
+		b.probing = true
+		b.setState(BreakerHalfOpen)
+		return true
+	case BreakerHalfOpen:
+		// Only one probe at a time
+		if b.probing {
+			b.mu.Unlock()
+			return false
+		}
+		b.probing = true
+	}
+
+	b.mu.Unlock()
+	return true
+}
+
+// Record reports the result of a request sent to the node.
+func (b *CircuitBreaker) Record(success bool, now time.Time) {
+	b.mu.Lock()
+
+	b.probing = false
+	if success {
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.setState(BreakerClosed)
+			return
+		}
+		b.mu.Unlock()
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.threshold > 0 && b.failures >= b.threshold) {
+		b.openedAt = now
+		b.setState(BreakerOpen)
+		return
+	}
+
+	b.mu.Unlock()
+}
+
+// setState updates the state & calls the callbacks (releasing the lock).
+func (b *CircuitBreaker) setState(state string) {
+	b.state = state
+	b.mu.Unlock()
+
+	for _, onStateChange := range b.onStateChange {
+		onStateChange(state)
+	}
+}
+
+// Release frees the probe slot of a request which did not complete (eg. canceled).
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		start   = time.Now()
+		breaker = NewCircuitBreaker(3, 30*time.Second)
+		states  = []string{}
+	)
+
+	breaker.OnStateChange(func(state string) {
+		states = append(states, state)
+	})
+
+	t.Run("Open After Failures", func(t *testing.T) {
+		breaker.Record(false, start)
+		breaker.Record(false, start)
+		breaker.Record(true, start)
+		assert.Equal(t, BreakerClosed, breaker.State())
+
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, true, breaker.Allow(start))
+			breaker.Record(false, start)
+		}
+		assert.Equal(t, BreakerOpen, breaker.State())
+		assert.Equal(t, false, breaker.Allow(start.Add(10*time.Second)))
+	})
+
+	t.Run("Half-Open Probe Failure", func(t *testing.T) {
+		assert.Equal(t, true, breaker.Allow(start.Add(31*time.Second)))
+		assert.Equal(t, BreakerHalfOpen, breaker.State())
+		assert.Equal(t, false, breaker.Allow(start.Add(31*time.Second))) // single probe
+
+		breaker.Record(false, start.Add(32*time.Second))
+		assert.Equal(t, BreakerOpen, breaker.State())
+	})
+
+	t.Run("Half-Open Probe Success", func(t *testing.T) {
+		assert.Equal(t, true, breaker.Allow(start.Add(70*time.Second)))
+		breaker.Record(true, start.Add(70*time.Second))
+		assert.Equal(t, BreakerClosed, breaker.State())
+	})
+
+	assert.DeepEqual(t, []string{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}, states)
+}
+
+func TestGuardedTransport(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	m := metrics.New("cosmos_validator_watcher")
+	breaker := NewCircuitBreaker(2, time.Minute)
+	client, err := NewClient(server.URL, ClientOptions{Breaker: breaker, Metrics: m})
+	assert.NilError(t, err)
+
+	node := NewNode(client, WithCircuitBreaker(breaker))
+	pool := NewPool("", []*Node{node})
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		client.Health(ctx)
+	}
+
+	// Requests are no longer sent once the breaker is open
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, true, node.IsCircuitOpen())
+	assert.Equal(t, true, pool.GetSyncedNode() == nil)
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.RPCCircuitBreaker.WithLabelValues(server.URL, BreakerOpen)))
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.RPCCircuitBreaker.WithLabelValues(server.URL, BreakerClosed)))
+}
+
+func TestRateLimiter(t *testing.T) {
+	start := time.Now()
+	limiter := NewRateLimiter(2, 2)
+	limiter.last = start
+
+	assert.Equal(t, time.Duration(0), limiter.reserve(start))
+	assert.Equal(t, time.Duration(0), limiter.reserve(start))
+	assert.Equal(t, 500*time.Millisecond, limiter.reserve(start))
+
+	// Refilled after a second
+	assert.Equal(t, time.Duration(0), limiter.reserve(start.Add(2*time.Second)))
+}
@@ -0,0 +1,157 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cometbft/cometbft/libs/bytes"
+	rpcclient "github.com/cometbft/cometbft/rpc/client"
+	"github.com/cometbft/cometbft/rpc/client/http"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"golang.org/x/sync/singleflight"
+)
+
+// QueryCache caches the responses of the module queries (ABCI queries) shared
+// by the watchers, keyed by path, height & request.
+//
+// Concurrent identical queries are collapsed into a single request.
+type QueryCache struct {
+	ttl     time.Duration
+	metrics *metrics.Metrics // optional
+	group   singleflight.Group
+
+	mu        sync.Mutex
+	entries   map[string]queryCacheEntry
+	lastPurge time.Time
+}
+
+type queryCacheEntry struct {
+	result    *ctypes.ResultABCIQuery
+	expiresAt time.Time
+}
+
+func NewQueryCache(ttl time.Duration, metrics *metrics.Metrics) *QueryCache {
+	return &QueryCache{
+		ttl:       ttl,
+		metrics:   metrics,
+		entries:   make(map[string]queryCacheEntry),
+		lastPurge: time.Now(),
+	}
+}
+
+// WithQueryCache runs the module queries of the node through a cache (shared
+// by all the nodes of the pool).
+func WithQueryCache(cache *QueryCache) NodeOption {
+	return func(n *Node) {
+		n.queryClient = &cachedClient{HTTP: n.Client, cache: cache}
+	}
+}
+
+// Query returns the cached response of a query or runs it.
+func (c *QueryCache) Query(ctx context.Context, path string, data bytes.HexBytes, height int64, query func(ctx context.Context) (*ctypes.ResultABCIQuery, error)) (*ctypes.ResultABCIQuery, error) {
+	key := fmt.Sprintf("%s/%d/%X", path, height, data)
+
+	if result, ok := c.get(key, time.Now()); ok {
+		c.record(path, true)
+		return result, nil
+	}
+
+	// Only one of the concurrent callers runs the query, the others share its
+	// result. The query is detached from the caller, which may give up before
+	// the others.
+	queried := false
+	resultChan := c.group.DoChan(key, func() (any, error) {
+		queried = true
+		result, err := query(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		// Failed queries (eg. unknown validator) are not cached
+		if result.Response.IsOK() {
+			c.set(key, result, time.Now())
+		}
+		return result, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-resultChan:
+		c.record(path, !queried)
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*ctypes.ResultABCIQuery), nil
+	}
+}
+
+// Len returns the number of cached responses (including expired ones not purged yet).
+func (c *QueryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+func (c *QueryCache) get(key string, now time.Time) (*ctypes.ResultABCIQuery, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || now.After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.result, true
+}
+
+func (c *QueryCache) set(key string, result *ctypes.ResultABCIQuery, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = queryCacheEntry{result: result, expiresAt: now.Add(c.ttl)}
+
+	// Forget expired entries (eg. queries at past heights)
+	if now.Sub(c.lastPurge) > c.ttl {
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastPurge = now
+	}
+}
+
+func (c *QueryCache) record(path string, hit bool) {
+	if c.metrics == nil {
+		return
+	}
+	if hit {
+		c.metrics.QueryCacheHits.WithLabelValues(path).Inc()
+	} else {
+		c.metrics.QueryCacheMisses.WithLabelValues(path).Inc()
+	}
+}
+
+// cachedClient is a node client running the ABCI queries through the cache.
+type cachedClient struct {
+	*http.HTTP
+	cache *QueryCache
+}
+
+func (c *cachedClient) ABCIQuery(ctx context.Context, path string, data bytes.HexBytes) (*ctypes.ResultABCIQuery, error) {
+	return c.ABCIQueryWithOptions(ctx, path, data, rpcclient.DefaultABCIQueryOptions)
+}
+
+func (c *cachedClient) ABCIQueryWithOptions(ctx context.Context, path string, data bytes.HexBytes, opts rpcclient.ABCIQueryOptions) (*ctypes.ResultABCIQuery, error) {
+	// Proofs are never requested by the watchers, don't cache them
+	if opts.Prove {
+		return c.HTTP.ABCIQueryWithOptions(ctx, path, data, opts)
+	}
+
+	return c.cache.Query(ctx, path, data, opts.Height, func(ctx context.Context) (*ctypes.ResultABCIQuery, error) {
+		return c.HTTP.ABCIQueryWithOptions(ctx, path, data, opts)
+	})
+}
@@ -0,0 +1,188 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rpcclient "github.com/cometbft/cometbft/rpc/client"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestQueryCache(t *testing.T) {
+	var (
+		queries atomic.Int32
+		release atomic.Value // chan struct{} closed to answer the queries
+	)
+	released := make(chan struct{})
+	close(released)
+	release.Store(released)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params struct {
+				Path string `json:"path"`
+			} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		queries.Add(1)
+		<-release.Load().(chan struct{})
+
+		code := 0
+		if req.Params.Path == "/unknown" {
+			code = 6
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"response":{"code":%d,"value":"AQI=","height":"100"}}}`, req.ID, code)
+	}))
+	defer server.Close()
+
+	const path = "/cosmos.staking.v1beta1.Query/Validators"
+
+	newNode := func(cache *QueryCache) *Node {
+		client, err := NewClient(server.URL, ClientOptions{})
+		assert.NilError(t, err)
+		return NewNode(client, WithQueryCache(cache))
+	}
+
+	t.Run("Cache Responses", func(t *testing.T) {
+		queries.Store(0)
+		m := metrics.New("cosmos_validator_watcher")
+		cache := NewQueryCache(time.Minute, m)
+
+		// The cache is shared by the nodes
+		nodeA, nodeB := newNode(cache), newNode(cache)
+		ctx := context.Background()
+
+		resp, err := nodeA.QueryClient().ABCIQueryWithOptions(ctx, path, []byte{1}, rpcclient.DefaultABCIQueryOptions)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []byte{1, 2}, resp.Response.Value)
+
+		_, err = nodeB.QueryClient().ABCIQueryWithOptions(ctx, path, []byte{1}, rpcclient.DefaultABCIQueryOptions)
+		assert.NilError(t, err)
+		_, err = nodeA.QueryClient().ABCIQuery(ctx, path, []byte{1})
+		assert.NilError(t, err)
+		assert.Equal(t, int32(1), queries.Load())
+
+		// Different request or height
+		_, err = nodeA.QueryClient().ABCIQueryWithOptions(ctx, path, []byte{2}, rpcclient.DefaultABCIQueryOptions)
+		assert.NilError(t, err)
+		_, err = nodeA.QueryClient().ABCIQueryWithOptions(ctx, path, []byte{1}, rpcclient.ABCIQueryOptions{Height: 42})
+		assert.NilError(t, err)
+		assert.Equal(t, int32(3), queries.Load())
+		assert.Equal(t, 3, cache.Len())
+
+		assert.Equal(t, float64(2), testutil.ToFloat64(m.QueryCacheHits.WithLabelValues(path)))
+		assert.Equal(t, float64(3), testutil.ToFloat64(m.QueryCacheMisses.WithLabelValues(path)))
+	})
+
+	t.Run("Skip Failed Queries", func(t *testing.T) {
+		queries.Store(0)
+		node := newNode(NewQueryCache(time.Minute, nil))
+
+		for i := 0; i < 2; i++ {
+			resp, err := node.QueryClient().ABCIQuery(context.Background(), "/unknown", nil)
+			assert.NilError(t, err)
+			assert.Equal(t, uint32(6), resp.Response.Code)
+		}
+		assert.Equal(t, int32(2), queries.Load())
+	})
+
+	t.Run("Expire Responses", func(t *testing.T) {
+		queries.Store(0)
+		cache := NewQueryCache(10*time.Millisecond, nil)
+		node := newNode(cache)
+
+		_, err := node.QueryClient().ABCIQuery(context.Background(), path, nil)
+		assert.NilError(t, err)
+		time.Sleep(20 * time.Millisecond)
+		_, err = node.QueryClient().ABCIQuery(context.Background(), path, nil)
+		assert.NilError(t, err)
+
+		assert.Equal(t, int32(2), queries.Load())
+		assert.Equal(t, 1, cache.Len()) // expired response purged
+	})
+
+	t.Run("Collapse Concurrent Queries", func(t *testing.T) {
+		queries.Store(0)
+		pending := make(chan struct{})
+		release.Store(pending)
+		defer release.Store(released)
+		m := metrics.New("cosmos_validator_watcher")
+		node := newNode(NewQueryCache(time.Minute, m))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := node.QueryClient().ABCIQuery(context.Background(), path, []byte{3})
+				assert.NilError(t, err)
+			}()
+		}
+
+		// Wait for the first query to reach the node before releasing it
+		for queries.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(pending)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), queries.Load())
+		assert.Equal(t, float64(5), testutil.ToFloat64(m.QueryCacheHits.WithLabelValues(path))+testutil.ToFloat64(m.QueryCacheMisses.WithLabelValues(path)))
+		assert.Equal(t, float64(1), testutil.ToFloat64(m.QueryCacheMisses.WithLabelValues(path)))
+	})
+
+	t.Run("Cancelled Caller", func(t *testing.T) {
+		queries.Store(0)
+		pending := make(chan struct{})
+		release.Store(pending)
+		defer release.Store(released)
+		node := newNode(NewQueryCache(time.Minute, nil))
+
+		// The first caller gives up while the query is in flight
+		ctx, cancel := context.WithCancel(context.Background())
+		first := make(chan error, 1)
+		go func() {
+			_, err := node.QueryClient().ABCIQuery(ctx, path, []byte{4})
+			first <- err
+		}()
+		for queries.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		second := make(chan error, 1)
+		go func() {
+			_, err := node.QueryClient().ABCIQuery(context.Background(), path, []byte{4})
+			second <- err
+		}()
+
+		cancel()
+		assert.Equal(t, context.Canceled, <-first)
+
+		// The collapsed caller still gets the response
+		close(pending)
+		assert.NilError(t, <-second)
+		assert.Equal(t, int32(1), queries.Load())
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		client, err := NewClient(server.URL, ClientOptions{})
+		assert.NilError(t, err)
+		node := NewNode(client)
+		assert.Equal(t, rpcclient.Client(client), node.QueryClient())
+	})
+}
@@ -0,0 +1,230 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	cmthttp "github.com/cometbft/cometbft/rpc/client/http"
+	jsonrpcclient "github.com/cometbft/cometbft/rpc/jsonrpc/client"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+)
+
+// ClientOptions configures the HTTP & websocket transports of a node.
+//
+// They are set per node with `__` query parameters in the endpoint (eg.
+// https://rpc.example.com?__header=X-Api-Key:secret&__ca=/etc/ssl/ca.pem),
+// which are removed from the endpoint before connecting to the node.
+type ClientOptions struct {
+	DisableWebsocket bool
+	Headers          http.Header
+	BearerToken      string
+	CertFile         string // client certificate (mTLS)
+	KeyFile          string // client key (mTLS)
+	CAFile           string
+	ProxyURL         *url.URL
+	RateLimit        float64 // maximum requests per second (0 for unlimited)
+
+	Breaker *CircuitBreaker
+	Metrics *metrics.Metrics
+}
+
+// ParseEndpoint extracts the client options from the endpoint query parameters.
+//
+// The returned endpoint is stripped from these parameters so secrets never
+// reach the logs or the metrics labels.
+func ParseEndpoint(endpoint string) (string, ClientOptions, error) {
+	options := ClientOptions{Headers: make(http.Header)}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", options, fmt.Errorf("failed to parse endpoint: %w", err)
+	}
+
+	query := u.Query()
+	for key, values := range query {
+		if !strings.HasPrefix(key, "__") {
+			continue
+		}
+		query.Del(key)
+
+		for _, value := range values {
+			switch key {
+			case "__websocket":
+				options.DisableWebsocket = value == "0"
+			case "__header":
+				name, val, ok := strings.Cut(value, ":")
+				if !ok || strings.TrimSpace(name) == "" {
+					return "", options, fmt.Errorf("invalid header (expected <name>:<value>)")
+				}
+				options.Headers.Add(strings.TrimSpace(name), strings.TrimSpace(val))
+			case "__bearer":
+				options.BearerToken = value
+			case "__cert":
+				options.CertFile = value
+			case "__key":
+				options.KeyFile = value
+			case "__ca":
+				options.CAFile = value
+			case "__rate_limit":
+				rateLimit, err := strconv.ParseFloat(value, 64)
+				if err != nil || rateLimit < 0 {
+					return "", options, fmt.Errorf("invalid rate limit: %s", value)
+				}
+				options.RateLimit = rateLimit
+			case "__proxy":
+				proxyURL, err := url.Parse(value)
+				if err != nil {
+					return "", options, fmt.Errorf("invalid proxy url: %w", err)
+				}
+				options.ProxyURL = proxyURL
+			default:
+				return "", options, fmt.Errorf("unknown endpoint option: %s", key)
+			}
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), options, nil
+}
+
+// HasTransportOptions reports if the options require a custom transport
+// (which the default CometBFT websocket client does not support).
+func (o ClientOptions) HasTransportOptions() bool {
+	return len(o.Headers) > 0 ||
+		o.BearerToken != "" ||
+		o.CertFile != "" ||
+		o.KeyFile != "" ||
+		o.CAFile != "" ||
+		o.ProxyURL != nil
+}
+
+// headers returns the extra headers to send with each request.
+func (o ClientOptions) headers() http.Header {
+	headers := o.Headers.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	if o.BearerToken != "" {
+		headers.Set("Authorization", "Bearer "+o.BearerToken)
+	}
+	return headers
+}
+
+func (o ClientOptions) tlsConfig() (*tls.Config, error) {
+	if o.CertFile == "" && o.KeyFile == "" && o.CAFile == "" {
+		return nil, nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, fmt.Errorf("both client certificate and key are required")
+		}
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if o.CAFile != "" {
+		ca, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in ca bundle %s", o.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+// NewClient creates a CometBFT client with a transport configured from the
+// options (headers, TLS, proxy, rate limit, circuit breaker & instrumentation).
+func NewClient(endpoint string, options ClientOptions) (*cmthttp.HTTP, error) {
+	httpClient, err := jsonrpcclient.DefaultHTTPClient(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create http client: %w", err)
+	}
+
+	transport, ok := httpClient.Transport.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("unexpected http transport %T", httpClient.Transport)
+	}
+
+	tlsConfig, err := options.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	if options.ProxyURL != nil {
+		// The default dialer always connects to the node, ignoring the proxy
+		transport.Dial = nil
+		transport.Proxy = http.ProxyURL(options.ProxyURL)
+	}
+
+	if headers := options.headers(); len(headers) > 0 {
+		httpClient.Transport = &headerTransport{next: httpClient.Transport, headers: headers}
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse endpoint: %w", err)
+	}
+
+	if options.Metrics != nil {
+		httpClient.Transport = newInstrumentedTransport(httpClient.Transport, options.Metrics, endpointLabel(u))
+
+		if options.Breaker != nil {
+			exportBreakerState(options.Metrics, endpointLabel(u), BreakerClosed)
+			options.Breaker.OnStateChange(func(state string) {
+				exportBreakerState(options.Metrics, endpointLabel(u), state)
+			})
+		}
+	}
+
+	if options.RateLimit > 0 || options.Breaker != nil {
+		guarded := &guardedTransport{next: httpClient.Transport, breaker: options.Breaker}
+		if options.RateLimit > 0 {
+			guarded.limiter = NewRateLimiter(options.RateLimit, int(math.Ceil(options.RateLimit)))
+		}
+		httpClient.Transport = guarded
+	}
+
+	return cmthttp.NewWithClient(endpoint, "/websocket", httpClient)
+}
+
+// headerTransport adds extra headers (eg. API keys) to each request.
+type headerTransport struct {
+	next    http.RoundTripper
+	headers http.Header
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		req.Header.Del(name)
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+func exportBreakerState(m *metrics.Metrics, endpoint string, state string) {
+	for _, s := range BreakerStates {
+		m.RPCCircuitBreaker.WithLabelValues(endpoint, s).Set(metrics.BoolToFloat64(s == state))
+	}
+}
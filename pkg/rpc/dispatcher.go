@@ -0,0 +1,171 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cometbft/cometbft/types"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"github.com/rs/zerolog/log"
+)
+
+// QueuePolicy is the behavior of a dispatcher when its queue is full.
+type QueuePolicy string
+
+const (
+	QueueDropOldest QueuePolicy = "drop-oldest" // discard the oldest queued event
+	QueueDropNewest QueuePolicy = "drop-newest" // discard the incoming event
+	QueueBlock      QueuePolicy = "block"       // wait for the watcher (backpressure on the nodes)
+)
+
+const (
+	DropReasonDuplicate = "duplicate"
+	DropReasonFull      = "full"
+)
+
+func ParseQueuePolicy(value string) (QueuePolicy, error) {
+	switch policy := QueuePolicy(value); policy {
+	case QueueDropOldest, QueueDropNewest, QueueBlock:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown queue policy: %s (expected drop-oldest, drop-newest or block)", value)
+	}
+}
+
+type DispatcherOptions struct {
+	QueueSize int
+	Policy    QueuePolicy
+	Metrics   *metrics.Metrics // optional
+}
+
+type queuedEvent struct {
+	node  *Node
+	event *ctypes.ResultEvent
+}
+
+// Dispatcher delivers the events of all the nodes to a watcher callback
+// through a bounded queue, so a slow watcher does not stall the nodes loops.
+//
+// New blocks are deduplicated across nodes: each height is queued once, in
+// increasing order (blocks at or below the latest queued height of a synced
+// node are dropped).
+type Dispatcher struct {
+	name     string
+	callback OnNodeEvent
+	options  DispatcherOptions
+	queue    chan queuedEvent
+
+	mu           sync.Mutex
+	latestHeight int64
+}
+
+func NewDispatcher(name string, callback OnNodeEvent, options DispatcherOptions) *Dispatcher {
+	if options.QueueSize <= 0 {
+		options.QueueSize = 1
+	}
+	if options.Policy == "" {
+		options.Policy = QueueDropOldest
+	}
+
+	return &Dispatcher{
+		name:     name,
+		callback: callback,
+		options:  options,
+		queue:    make(chan queuedEvent, options.QueueSize),
+	}
+}
+
+// OnNodeEvent queues an event, to be registered on the pool in place of the
+// watcher callback.
+func (d *Dispatcher) OnNodeEvent(ctx context.Context, n *Node, event *ctypes.ResultEvent) error {
+	if block, ok := event.Data.(types.EventDataNewBlock); ok && block.Block != nil {
+		d.mu.Lock()
+		duplicate := block.Block.Height <= d.latestHeight
+		// Watchers may ignore the blocks of unsynced nodes, so their heights are
+		// not recorded (to let the copies of synced nodes through)
+		if !duplicate && n.IsSynced() {
+			d.latestHeight = block.Block.Height
+		}
+		d.mu.Unlock()
+
+		if duplicate {
+			d.drop(DropReasonDuplicate)
+			return nil
+		}
+	}
+
+	item := queuedEvent{node: n, event: event}
+
+	switch d.options.Policy {
+	case QueueBlock:
+		select {
+		case d.queue <- item:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+	case QueueDropNewest:
+		select {
+		case d.queue <- item:
+		default:
+			d.drop(DropReasonFull)
+		}
+
+	case QueueDropOldest:
+		for {
+			select {
+			case d.queue <- item:
+				d.exportDepth()
+				return nil
+			default:
+			}
+
+			// Make room by discarding the oldest event (unless consumed in the meantime)
+			select {
+			case <-d.queue:
+				d.drop(DropReasonFull)
+			default:
+			}
+		}
+	}
+
+	d.exportDepth()
+	return nil
+}
+
+// Start delivers the queued events to the watcher, one at a time.
+func (d *Dispatcher) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case item := <-d.queue:
+			d.exportDepth()
+			if err := d.callback(ctx, item.node, item.event); err != nil {
+				log.Error().Err(err).Str("watcher", d.name).Msg("failed to call event callback")
+			}
+		}
+	}
+}
+
+// Len returns the number of queued events.
+func (d *Dispatcher) Len() int {
+	return len(d.queue)
+}
+
+func (d *Dispatcher) drop(reason string) {
+	if reason == DropReasonFull {
+		log.Warn().Str("watcher", d.name).Msg("event queue is full, dropping event")
+	}
+	if d.options.Metrics != nil {
+		d.options.Metrics.EventQueueDropped.WithLabelValues(d.name, reason).Inc()
+	}
+}
+
+func (d *Dispatcher) exportDepth() {
+	if d.options.Metrics != nil {
+		d.options.Metrics.EventQueueDepth.WithLabelValues(d.name).Set(float64(len(d.queue)))
+	}
+}
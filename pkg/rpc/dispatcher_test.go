@@ -0,0 +1,156 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cometbft/cometbft/types"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	blockEvent := func(height int64) *ctypes.ResultEvent {
+		return newBlockEvent(&types.Block{Header: types.Header{Height: height}})
+	}
+
+	queuedHeights := func(d *Dispatcher) []int64 {
+		heights := []int64{}
+		for d.Len() > 0 {
+			item := <-d.queue
+			heights = append(heights, item.event.Data.(types.EventDataNewBlock).Block.Height)
+		}
+		return heights
+	}
+
+	noop := func(ctx context.Context, n *Node, event *ctypes.ResultEvent) error { return nil }
+
+	syncedNode := func() *Node {
+		status := &ctypes.ResultStatus{}
+		status.SyncInfo.LatestBlockTime = time.Now()
+
+		node := &Node{}
+		node.status.Store(status)
+		return node
+	}
+
+	t.Run("Parse Queue Policy", func(t *testing.T) {
+		policy, err := ParseQueuePolicy("drop-newest")
+		assert.NilError(t, err)
+		assert.Equal(t, QueueDropNewest, policy)
+
+		_, err = ParseQueuePolicy("drop-all")
+		assert.ErrorContains(t, err, "unknown queue policy")
+	})
+
+	t.Run("Deduplicate Heights", func(t *testing.T) {
+		m := metrics.New("cosmos_validator_watcher")
+		d := NewDispatcher("block", noop, DispatcherOptions{QueueSize: 10, Metrics: m})
+		nodeA, nodeB := syncedNode(), syncedNode()
+
+		d.OnNodeEvent(ctx, nodeA, blockEvent(10))
+		d.OnNodeEvent(ctx, nodeB, blockEvent(10))
+		d.OnNodeEvent(ctx, nodeB, blockEvent(11))
+		d.OnNodeEvent(ctx, nodeA, blockEvent(11))
+		d.OnNodeEvent(ctx, nodeA, blockEvent(9))
+
+		assert.Equal(t, float64(3), testutil.ToFloat64(m.EventQueueDropped.WithLabelValues("block", DropReasonDuplicate)))
+		assert.Equal(t, float64(2), testutil.ToFloat64(m.EventQueueDepth.WithLabelValues("block")))
+		assert.DeepEqual(t, []int64{10, 11}, queuedHeights(d))
+	})
+
+	t.Run("Unsynced Node First", func(t *testing.T) {
+		d := NewDispatcher("block", noop, DispatcherOptions{QueueSize: 10})
+		unsynced, synced := &Node{}, syncedNode()
+
+		// The copy of the synced node is queued as well
+		d.OnNodeEvent(ctx, unsynced, blockEvent(10))
+		d.OnNodeEvent(ctx, synced, blockEvent(10))
+		d.OnNodeEvent(ctx, unsynced, blockEvent(10))
+		assert.DeepEqual(t, []int64{10, 10}, queuedHeights(d))
+	})
+
+	t.Run("Drop Oldest", func(t *testing.T) {
+		m := metrics.New("cosmos_validator_watcher")
+		d := NewDispatcher("block", noop, DispatcherOptions{QueueSize: 2, Policy: QueueDropOldest, Metrics: m})
+
+		for height := int64(1); height <= 4; height++ {
+			d.OnNodeEvent(ctx, &Node{}, blockEvent(height))
+		}
+
+		assert.Equal(t, float64(2), testutil.ToFloat64(m.EventQueueDropped.WithLabelValues("block", DropReasonFull)))
+		assert.DeepEqual(t, []int64{3, 4}, queuedHeights(d))
+	})
+
+	t.Run("Drop Newest", func(t *testing.T) {
+		m := metrics.New("cosmos_validator_watcher")
+		d := NewDispatcher("block", noop, DispatcherOptions{QueueSize: 2, Policy: QueueDropNewest, Metrics: m})
+
+		for height := int64(1); height <= 4; height++ {
+			d.OnNodeEvent(ctx, &Node{}, blockEvent(height))
+		}
+
+		assert.Equal(t, float64(2), testutil.ToFloat64(m.EventQueueDropped.WithLabelValues("block", DropReasonFull)))
+		assert.DeepEqual(t, []int64{1, 2}, queuedHeights(d))
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		d := NewDispatcher("block", noop, DispatcherOptions{QueueSize: 1, Policy: QueueBlock})
+
+		assert.NilError(t, d.OnNodeEvent(ctx, &Node{}, blockEvent(1)))
+
+		// Waits for the watcher until the context is done
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		assert.Equal(t, context.DeadlineExceeded, d.OnNodeEvent(timeoutCtx, &Node{}, blockEvent(2)))
+		assert.DeepEqual(t, []int64{1}, queuedHeights(d))
+	})
+
+	t.Run("Block Without Locking", func(t *testing.T) {
+		d := NewDispatcher("block", noop, DispatcherOptions{QueueSize: 1, Policy: QueueBlock})
+		node := syncedNode()
+
+		assert.NilError(t, d.OnNodeEvent(ctx, node, blockEvent(1)))
+
+		blockedCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go d.OnNodeEvent(blockedCtx, node, blockEvent(2))
+
+		// Duplicates are still dropped while a producer waits for the watcher
+		done := make(chan error)
+		go func() {
+			done <- d.OnNodeEvent(ctx, node, blockEvent(1))
+		}()
+		select {
+		case err := <-done:
+			assert.NilError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("dispatcher locked by a blocked producer")
+		}
+	})
+
+	t.Run("Deliver Events", func(t *testing.T) {
+		received := make(chan int64)
+		d := NewDispatcher("block", func(ctx context.Context, n *Node, event *ctypes.ResultEvent) error {
+			received <- event.Data.(types.EventDataNewBlock).Block.Height
+			return nil
+		}, DispatcherOptions{QueueSize: 10})
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go d.Start(ctx)
+
+		// Producers are not blocked by the watcher
+		for height := int64(1); height <= 3; height++ {
+			d.OnNodeEvent(ctx, &Node{}, blockEvent(height))
+		}
+		for height := int64(1); height <= 3; height++ {
+			assert.Equal(t, height, <-received)
+		}
+	})
+}
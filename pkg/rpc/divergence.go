@@ -0,0 +1,412 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cometbft/cometbft/libs/bytes"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cometbft/cometbft/types"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Number of heights kept to compare hashes across nodes
+	divergenceHistory = 100
+
+	// Duration after which a node stuck at the same height is considered stale
+	staleThreshold = time.Minute
+
+	// Maximum duration a block is held while waiting for the other nodes to
+	// report the same height
+	blockHoldTimeout = 5 * time.Second
+)
+
+// Divergence describes a node disagreeing with the other nodes of the pool.
+type Divergence struct {
+	Kind     string `json:"kind"` // same as the quarantine reason (forked, app_hash_mismatch or stale)
+	Height   int64  `json:"height"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+type OnNodeDivergence func(ctx context.Context, n *Node, kind string, divergence *Divergence) error
+
+// divergenceTracker compares the hashes reported by each node at the same heights.
+//
+// Blocks are held until the pool agrees on their hash, so blocks of a forked
+// node are never forwarded to the watchers.
+type divergenceTracker struct {
+	mu          sync.Mutex
+	nodes       []*Node
+	pending     map[int64][]heldBlock      // blocks waiting for a verdict on their hash
+	blockHashes map[int64]map[*Node]string // block hash reported by each node
+	appHashes   map[int64]map[*Node]string // app hash after the block, from the next block header
+	stateHashes map[int64]map[*Node]string // app hash computed by each node (abci_info)
+	progress    map[*Node]nodeProgress     // latest height reported in the node status
+	maxHeight   int64
+
+	// Held blocks are forwarded one verdict at a time to keep the heights in order
+	releaseMu sync.Mutex
+
+	onDivergence []OnNodeDivergence
+}
+
+type heldBlock struct {
+	node  *Node
+	hash  string
+	event *ctypes.ResultEvent
+}
+
+type nodeProgress struct {
+	height int64
+	since  time.Time
+}
+
+type divergenceUpdate struct {
+	node       *Node
+	kind       string
+	divergence *Divergence // nil when the node agrees with the pool
+}
+
+func newDivergenceTracker() *divergenceTracker {
+	return &divergenceTracker{
+		blockHashes: make(map[int64]map[*Node]string),
+		appHashes:   make(map[int64]map[*Node]string),
+		stateHashes: make(map[int64]map[*Node]string),
+		progress:    make(map[*Node]nodeProgress),
+		pending:     make(map[int64][]heldBlock),
+	}
+}
+
+// reportBlock records the hashes of a block received by a node and holds the
+// block until the pool agrees on its hash.
+func (t *divergenceTracker) reportBlock(ctx context.Context, n *Node, block *types.Block, event *ctypes.ResultEvent) {
+	// Nodes of another network are not compared (nor forwarded)
+	if n.isQuarantinedFor(QuarantineChainIDMismatch) {
+		return
+	}
+
+	hash := block.Hash().String()
+
+	t.mu.Lock()
+	record(t.blockHashes, block.Height, n, hash)
+	record(t.appHashes, block.Height-1, n, block.AppHash.String())
+	t.updateMaxHeight(n, block.Height)
+	updates := append(t.checkBlockHashes(block.Height), t.checkStateHashes(block.Height-1)...)
+
+	// Blocks which cannot be compared are forwarded right away
+	hold := hash != "" && block.Height >= t.maxHeight-divergenceHistory
+	if hold {
+		if _, ok := t.pending[block.Height]; !ok {
+			time.AfterFunc(blockHoldTimeout, func() {
+				t.release(ctx, block.Height, true)
+			})
+		}
+		t.pending[block.Height] = append(t.pending[block.Height], heldBlock{node: n, hash: hash, event: event})
+	}
+	t.mu.Unlock()
+
+	t.apply(ctx, updates)
+
+	if hold {
+		t.release(ctx, block.Height, false)
+	} else {
+		n.handleEvent(ctx, EventNewBlock, event)
+	}
+}
+
+// reportStatus records the latest block reported by a node status and checks
+// the node is still making progress.
+func (t *divergenceTracker) reportStatus(ctx context.Context, n *Node, status *ctypes.ResultStatus) {
+	if n.isQuarantinedFor(QuarantineChainIDMismatch) {
+		return
+	}
+
+	height := status.SyncInfo.LatestBlockHeight
+
+	t.mu.Lock()
+	record(t.blockHashes, height, n, status.SyncInfo.LatestBlockHash.String())
+	record(t.appHashes, height-1, n, status.SyncInfo.LatestAppHash.String())
+	t.updateMaxHeight(n, height)
+	updates := append(t.checkBlockHashes(height), t.checkStateHashes(height-1)...)
+	updates = append(updates, t.checkProgress(n, height, time.Now())...)
+	t.mu.Unlock()
+
+	t.apply(ctx, updates)
+	t.release(ctx, height, false)
+}
+
+// reportStateHash records the app hash computed by a node after a block.
+func (t *divergenceTracker) reportStateHash(ctx context.Context, n *Node, height int64, appHash string) {
+	t.mu.Lock()
+	record(t.stateHashes, height, n, appHash)
+	updates := t.checkStateHashes(height)
+	t.mu.Unlock()
+
+	t.apply(ctx, updates)
+}
+
+// updateMaxHeight raises the highest height of the pool (quarantined nodes are
+// ignored, as Pool.MaxHeight does).
+func (t *divergenceTracker) updateMaxHeight(n *Node, height int64) {
+	if height <= t.maxHeight || n.IsQuarantined() {
+		return
+	}
+	t.maxHeight = height
+
+	// Forget old heights
+	for _, hashes := range []map[int64]map[*Node]string{t.blockHashes, t.appHashes, t.stateHashes} {
+		for h := range hashes {
+			if h < t.maxHeight-divergenceHistory {
+				delete(hashes, h)
+			}
+		}
+	}
+}
+
+// checkProgress flags nodes stuck at the same height while other nodes moved forward.
+func (t *divergenceTracker) checkProgress(n *Node, height int64, now time.Time) []divergenceUpdate {
+	progress, known := t.progress[n]
+	if !known || height > progress.height {
+		t.progress[n] = nodeProgress{height: height, since: now}
+		return []divergenceUpdate{{node: n, kind: QuarantineStale}}
+	}
+
+	if t.maxHeight <= progress.height || now.Sub(progress.since) < staleThreshold {
+		return nil
+	}
+
+	return []divergenceUpdate{{node: n, kind: QuarantineStale, divergence: &Divergence{
+		Kind:     QuarantineStale,
+		Height:   progress.height,
+		Expected: fmt.Sprintf("%d", t.maxHeight),
+		Actual:   fmt.Sprintf("%d", progress.height),
+	}}}
+}
+
+// checkBlockHashes flags nodes with a block hash different from the majority.
+func (t *divergenceTracker) checkBlockHashes(height int64) []divergenceUpdate {
+	expected, ok := majority(t.blockHashes[height], 2)
+	if !ok {
+		return nil
+	}
+
+	updates := []divergenceUpdate{}
+	for node, hash := range t.blockHashes[height] {
+		update := divergenceUpdate{node: node, kind: QuarantineForked}
+		if hash != expected {
+			update.divergence = &Divergence{Kind: QuarantineForked, Height: height, Expected: expected, Actual: hash}
+		}
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+// checkStateHashes flags nodes which computed an app hash different from the
+// one agreed in the next block header.
+func (t *divergenceTracker) checkStateHashes(height int64) []divergenceUpdate {
+	expected, ok := majority(t.appHashes[height], 1)
+	if !ok {
+		return nil
+	}
+
+	updates := []divergenceUpdate{}
+	for node, hash := range t.stateHashes[height] {
+		update := divergenceUpdate{node: node, kind: QuarantineAppHashMismatch}
+		if hash != expected {
+			update.divergence = &Divergence{Kind: QuarantineAppHashMismatch, Height: height, Expected: expected, Actual: hash}
+		}
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+// release forwards the held blocks of the given height once the pool agrees
+// on their hash (along with the blocks still held at lower heights).
+//
+// When expired, the blocks are forwarded if their hash has the majority among
+// the reports received so far, and dropped otherwise.
+func (t *divergenceTracker) release(ctx context.Context, height int64, expired bool) {
+	t.mu.Lock()
+	blocks, ok := t.settle(height, expired)
+	if ok {
+		heights := []int64{}
+		for h := range t.pending {
+			if h < height {
+				heights = append(heights, h)
+			}
+		}
+		sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+
+		// Earlier heights are settled with the reports received so far
+		lower := []heldBlock{}
+		for _, h := range heights {
+			released, _ := t.settle(h, true)
+			lower = append(lower, released...)
+		}
+		blocks = append(lower, blocks...)
+	}
+	t.releaseMu.Lock()
+	t.mu.Unlock()
+	defer t.releaseMu.Unlock()
+
+	for _, held := range blocks {
+		held.node.handleEvent(ctx, EventNewBlock, held.event)
+	}
+}
+
+// settle returns the held blocks of the given height agreeing with the pool and
+// reports if a verdict was reached (the height is no longer held then).
+func (t *divergenceTracker) settle(height int64, expired bool) ([]heldBlock, bool) {
+	held, ok := t.pending[height]
+	if !ok {
+		return nil, false
+	}
+
+	// Only the nodes still in use take part in the verdict
+	hashes := make(map[*Node]string)
+	for node, hash := range t.blockHashes[height] {
+		if !node.IsQuarantined() {
+			hashes[node] = hash
+		}
+	}
+
+	minReports := 2
+	if expired || t.activeNodes() < 2 {
+		minReports = 1
+	}
+
+	expected, ok := majority(hashes, minReports)
+	if !ok && !expired {
+		return nil, false
+	}
+	delete(t.pending, height)
+
+	if !ok {
+		log.Warn().Int64("height", height).Msg("nodes do not agree on the block hash, dropping block")
+		return nil, true
+	}
+
+	blocks := []heldBlock{}
+	for _, block := range held {
+		if block.hash == expected {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks, true
+}
+
+// activeNodes returns the number of nodes of the pool not quarantined.
+func (t *divergenceTracker) activeNodes() int {
+	count := 0
+	for _, node := range t.nodes {
+		if !node.IsQuarantined() {
+			count++
+		}
+	}
+	return count
+}
+
+func (t *divergenceTracker) apply(ctx context.Context, updates []divergenceUpdate) {
+	for _, update := range updates {
+		if !update.node.setDivergence(update.kind, update.divergence) {
+			continue
+		}
+
+		if update.divergence != nil {
+			update.node.Quarantine(update.kind, fmt.Sprintf("diverged at height %d (expected %s, got %s)",
+				update.divergence.Height, update.divergence.Expected, update.divergence.Actual))
+		} else {
+			update.node.Release(update.kind)
+		}
+
+		for _, onDivergence := range t.onDivergence {
+			if err := onDivergence(ctx, update.node, update.kind, update.divergence); err != nil {
+				log.Error().Err(err).Str("node", update.node.Redacted()).Msg("failed to call divergence callback")
+			}
+		}
+	}
+}
+
+func record(hashes map[int64]map[*Node]string, height int64, n *Node, hash string) {
+	if hash == "" || height <= 0 {
+		return
+	}
+	if _, ok := hashes[height]; !ok {
+		hashes[height] = make(map[*Node]string)
+	}
+	hashes[height][n] = hash
+}
+
+// majority returns the hash reported by more than half of the nodes.
+func majority(hashes map[*Node]string, minReports int) (string, bool) {
+	if len(hashes) < minReports {
+		return "", false
+	}
+
+	counts := make(map[string]int)
+	for _, hash := range hashes {
+		counts[hash]++
+	}
+	for hash, count := range counts {
+		if count*2 > len(hashes) {
+			return hash, true
+		}
+	}
+	return "", false
+}
+
+// dispatchBlock reports a block received by the node and forwards it to the
+// watchers once the other nodes of the pool agree on its hash.
+func (n *Node) dispatchBlock(ctx context.Context, block *types.Block, event *ctypes.ResultEvent) {
+	if n.divergence == nil || block == nil {
+		n.handleEvent(ctx, EventNewBlock, event)
+		return
+	}
+	n.divergence.reportBlock(ctx, n, block, event)
+}
+
+func (n *Node) reportStatus(ctx context.Context, status *ctypes.ResultStatus) {
+	if n.divergence == nil {
+		return
+	}
+	n.divergence.reportStatus(ctx, n, status)
+}
+
+func (n *Node) reportStateHash(ctx context.Context, height int64, appHash []byte) {
+	if n.divergence == nil || len(appHash) == 0 {
+		return
+	}
+	n.divergence.reportStateHash(ctx, n, height, bytes.HexBytes(appHash).String())
+}
+
+// setDivergence updates the divergence state of the node and reports if it changed.
+func (n *Node) setDivergence(kind string, divergence *Divergence) bool {
+	n.quarantineMu.Lock()
+	defer n.quarantineMu.Unlock()
+
+	_, diverged := n.divergences[kind]
+	if divergence == nil {
+		delete(n.divergences, kind)
+		return diverged
+	}
+
+	n.divergences[kind] = divergence
+	return !diverged
+}
+
+// Divergences returns the ongoing divergences of the node by kind.
+func (n *Node) Divergences() map[string]*Divergence {
+	n.quarantineMu.RLock()
+	defer n.quarantineMu.RUnlock()
+
+	divergences := make(map[string]*Divergence, len(n.divergences))
+	for kind, divergence := range n.divergences {
+		divergences[kind] = divergence
+	}
+	return divergences
+}
@@ -0,0 +1,199 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cometbft/cometbft/rpc/client/http"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cometbft/cometbft/types"
+	"gotest.tools/assert"
+)
+
+func TestDivergence(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		nodes = make([]*Node, 3)
+	)
+
+	for i := range nodes {
+		client, err := http.New("http://localhost:26657", "/websocket")
+		assert.NilError(t, err)
+		nodes[i] = NewNode(client)
+	}
+
+	pool := NewPool("chain-42", nodes)
+	tracker := pool.divergence
+
+	divergences := 0
+	pool.OnNodeDivergence(func(ctx context.Context, n *Node, kind string, divergence *Divergence) error {
+		divergences++
+		return nil
+	})
+
+	t.Run("Forked Node", func(t *testing.T) {
+		tracker.mu.Lock()
+		record(tracker.blockHashes, 100, nodes[0], "AAAA")
+		record(tracker.blockHashes, 100, nodes[1], "AAAA")
+		record(tracker.blockHashes, 100, nodes[2], "BBBB")
+		updates := tracker.checkBlockHashes(100)
+		tracker.mu.Unlock()
+		tracker.apply(ctx, updates)
+
+		assert.Equal(t, false, nodes[0].IsQuarantined())
+		assert.DeepEqual(t, []string{QuarantineForked}, nodes[2].QuarantineReasons())
+		assert.Equal(t, int64(100), nodes[2].Divergences()[QuarantineForked].Height)
+		assert.Equal(t, 1, divergences)
+
+		// Matching again at a later height
+		tracker.mu.Lock()
+		record(tracker.blockHashes, 101, nodes[0], "CCCC")
+		record(tracker.blockHashes, 101, nodes[2], "CCCC")
+		updates = tracker.checkBlockHashes(101)
+		tracker.mu.Unlock()
+		tracker.apply(ctx, updates)
+
+		assert.Equal(t, false, nodes[2].IsQuarantined())
+		assert.Equal(t, 2, divergences)
+	})
+
+	t.Run("No Majority", func(t *testing.T) {
+		tracker.mu.Lock()
+		record(tracker.blockHashes, 102, nodes[0], "DDDD")
+		record(tracker.blockHashes, 102, nodes[1], "EEEE")
+		updates := tracker.checkBlockHashes(102)
+		tracker.mu.Unlock()
+
+		assert.Equal(t, 0, len(updates))
+	})
+
+	t.Run("App Hash Mismatch", func(t *testing.T) {
+		tracker.mu.Lock()
+		record(tracker.appHashes, 110, nodes[0], "1111")
+		record(tracker.stateHashes, 110, nodes[0], "1111")
+		record(tracker.stateHashes, 110, nodes[1], "2222")
+		updates := tracker.checkStateHashes(110)
+		tracker.mu.Unlock()
+		tracker.apply(ctx, updates)
+
+		assert.Equal(t, false, nodes[0].IsQuarantined())
+		assert.DeepEqual(t, []string{QuarantineAppHashMismatch}, nodes[1].QuarantineReasons())
+	})
+
+	t.Run("Stale Node", func(t *testing.T) {
+		start := time.Now()
+
+		tracker.mu.Lock()
+		tracker.maxHeight = 120
+		updates := tracker.checkProgress(nodes[2], 115, start)
+		updates = append(updates, tracker.checkProgress(nodes[2], 115, start.Add(30*time.Second))...)
+		tracker.mu.Unlock()
+		tracker.apply(ctx, updates)
+		assert.Equal(t, false, nodes[2].IsQuarantined())
+
+		tracker.mu.Lock()
+		updates = tracker.checkProgress(nodes[2], 115, start.Add(2*time.Minute))
+		tracker.mu.Unlock()
+		tracker.apply(ctx, updates)
+		assert.DeepEqual(t, []string{QuarantineStale}, nodes[2].QuarantineReasons())
+
+		tracker.mu.Lock()
+		updates = tracker.checkProgress(nodes[2], 121, start.Add(3*time.Minute))
+		tracker.mu.Unlock()
+		tracker.apply(ctx, updates)
+		assert.Equal(t, false, nodes[2].IsQuarantined())
+	})
+}
+
+func TestDivergenceHeldBlocks(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		nodes = make([]*Node, 3)
+	)
+
+	type received struct {
+		node   int
+		height int64
+	}
+	events := make(chan received, 10)
+
+	for i := range nodes {
+		client, err := http.New("http://localhost:26657", "/websocket")
+		assert.NilError(t, err)
+		nodes[i] = NewNode(client)
+
+		i := i
+		nodes[i].OnEvent(EventNewBlock, func(ctx context.Context, n *Node, event *ctypes.ResultEvent) error {
+			events <- received{node: i, height: event.Data.(types.EventDataNewBlock).Block.Height}
+			return nil
+		})
+	}
+
+	NewPool("chain-42", nodes)
+
+	newBlock := func(height int64, chainID string) *types.Block {
+		return &types.Block{
+			Header:     types.Header{ChainID: chainID, Height: height, ValidatorsHash: []byte{0x42}},
+			LastCommit: &types.Commit{Height: height - 1},
+		}
+	}
+
+	dispatch := func(node int, block *types.Block) {
+		nodes[node].dispatchBlock(ctx, block, newBlockEvent(block))
+	}
+
+	// The forked node reports first, its block is held
+	dispatch(2, newBlock(100, "fork-42"))
+	dispatch(0, newBlock(100, "chain-42"))
+	assert.Equal(t, 0, len(events))
+
+	// The majority is reached with the third node
+	dispatch(1, newBlock(100, "chain-42"))
+	assert.DeepEqual(t, []string{QuarantineForked}, nodes[2].QuarantineReasons())
+	assert.Equal(t, 2, len(events))
+	for i := 0; i < 2; i++ {
+		evt := <-events
+		assert.Equal(t, int64(100), evt.height)
+		assert.Assert(t, evt.node != 2)
+	}
+
+	// Blocks agreeing with the pool are forwarded as soon as two nodes report them
+	dispatch(0, newBlock(101, "chain-42"))
+	assert.Equal(t, 0, len(events))
+	dispatch(1, newBlock(101, "chain-42"))
+	assert.Equal(t, 2, len(events))
+}
+
+func TestDivergenceChainIDMismatch(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		nodes = make([]*Node, 2)
+		start = time.Now()
+	)
+
+	for i := range nodes {
+		client, err := http.New("http://localhost:26657", "/websocket")
+		assert.NilError(t, err)
+		nodes[i] = NewNode(client)
+	}
+
+	pool := NewPool("chain-42", nodes)
+	tracker := pool.divergence
+
+	// The second node is on another network, far ahead of the pool
+	nodes[1].checkChainID("chain-43")
+	status := &ctypes.ResultStatus{}
+	status.SyncInfo.LatestBlockHeight = 9_000_000
+	tracker.reportStatus(ctx, nodes[1], status)
+	assert.Equal(t, int64(0), tracker.maxHeight)
+
+	// The node of the expected network is not considered stale
+	tracker.mu.Lock()
+	tracker.checkProgress(nodes[0], 100, start)
+	updates := tracker.checkProgress(nodes[0], 100, start.Add(2*staleThreshold))
+	tracker.mu.Unlock()
+	tracker.apply(ctx, updates)
+	assert.Equal(t, false, nodes[0].IsQuarantined())
+	assert.DeepEqual(t, []string{QuarantineChainIDMismatch}, nodes[1].QuarantineReasons())
+}
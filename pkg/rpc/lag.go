@@ -0,0 +1,73 @@
+package rpc
+
+type PoolOption func(*Pool)
+
+// MaxLag excludes nodes lagging more than the given number of blocks behind
+// the highest node of the pool (0 to disable).
+func MaxLag(blocks int64) PoolOption {
+	return func(p *Pool) {
+		p.maxLag = blocks
+	}
+}
+
+// LatestHeight returns the highest block height known by the node (from its
+// status or latest received block).
+func (n *Node) LatestHeight() int64 {
+	height := int64(0)
+	if status := n.loadStatus(); status != nil {
+		height = status.SyncInfo.LatestBlockHeight
+	}
+	if block := n.getLatestBlock(); block != nil && block.Height > height {
+		height = block.Height
+	}
+	return height
+}
+
+// Lag returns the number of blocks the node is behind the highest node of its pool.
+func (n *Node) Lag() int64 {
+	if n.pool == nil {
+		return 0
+	}
+	return n.pool.Lag(n)
+}
+
+// IsLagging reports if the node lags beyond the threshold of its pool.
+func (n *Node) IsLagging() bool {
+	if n.pool == nil {
+		return false
+	}
+	return n.pool.IsLagging(n)
+}
+
+// MaxHeight returns the highest block height across the nodes of the pool.
+//
+// Quarantined nodes are ignored as they may report heights of another network.
+func (p *Pool) MaxHeight() int64 {
+	maxHeight := int64(0)
+	for _, node := range p.Nodes {
+		if node.IsQuarantined() {
+			continue
+		}
+		if height := node.LatestHeight(); height > maxHeight {
+			maxHeight = height
+		}
+	}
+	return maxHeight
+}
+
+func (p *Pool) Lag(n *Node) int64 {
+	height := n.LatestHeight()
+	if height == 0 {
+		return 0
+	}
+
+	lag := p.MaxHeight() - height
+	if lag < 0 {
+		return 0
+	}
+	return lag
+}
+
+func (p *Pool) IsLagging(n *Node) bool {
+	return p.maxLag > 0 && p.Lag(n) > p.maxLag
+}
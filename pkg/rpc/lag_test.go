@@ -0,0 +1,41 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cometbft/cometbft/rpc/client/http"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"gotest.tools/assert"
+)
+
+func TestLag(t *testing.T) {
+	nodes := make([]*Node, 2)
+	for i := range nodes {
+		client, err := http.New("http://localhost:26657", "/websocket")
+		assert.NilError(t, err)
+		nodes[i] = NewNode(client)
+	}
+
+	setHeight := func(n *Node, height int64) {
+		n.status.Store(&ctypes.ResultStatus{SyncInfo: ctypes.SyncInfo{
+			LatestBlockHeight: height,
+			LatestBlockTime:   time.Now(),
+		}})
+	}
+
+	pool := NewPool("", nodes, MaxLag(10))
+
+	setHeight(nodes[0], 100)
+	setHeight(nodes[1], 130)
+	assert.Equal(t, int64(130), pool.MaxHeight())
+	assert.Equal(t, int64(30), nodes[0].Lag())
+	assert.Equal(t, true, nodes[0].IsLagging())
+	assert.Equal(t, false, nodes[1].IsLagging())
+	assert.Equal(t, nodes[1], pool.GetSyncedNode())
+
+	// Recovered
+	setHeight(nodes[0], 125)
+	assert.Equal(t, false, nodes[0].IsLagging())
+	assert.Equal(t, nodes[0], pool.GetSyncedNode())
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/avast/retry-go/v4"
+	rpcclient "github.com/cometbft/cometbft/rpc/client"
 	"github.com/cometbft/cometbft/rpc/client/http"
 	ctypes "github.com/cometbft/cometbft/rpc/core/types"
 	"github.com/cometbft/cometbft/types"
@@ -24,6 +25,9 @@ const (
 	EventVote                = "Vote"
 )
 
+// Interval between two status syncs
+const statusInterval = 30 * time.Second
+
 type OnNodeEvent func(ctx context.Context, n *Node, event *ctypes.ResultEvent) error
 type OnNodeStart func(ctx context.Context, n *Node) error
 type OnNodeStatus func(ctx context.Context, n *Node, status *ctypes.ResultStatus) error
@@ -36,20 +40,64 @@ func DisableWebsocket() NodeOption {
 	}
 }
 
+// WithCircuitBreaker takes the node out of the pool while its breaker is not closed.
+//
+// The breaker must also be set in the client options to guard the requests.
+func WithCircuitBreaker(breaker *CircuitBreaker) NodeOption {
+	return func(n *Node) {
+		n.breaker = breaker
+		breaker.OnStateChange(func(state string) {
+			switch state {
+			case BreakerOpen:
+				log.Warn().Str("node", n.Redacted()).Msg("circuit breaker opened, node taken out of use")
+			case BreakerHalfOpen:
+				log.Info().Str("node", n.Redacted()).Msg("circuit breaker half-open, probing node")
+			case BreakerClosed:
+				log.Info().Str("node", n.Redacted()).Msg("circuit breaker closed, node back in use")
+			}
+		})
+	}
+}
+
+// WithEventsClient subscribes to the node events with the given client
+// instead of the CometBFT websocket client.
+func WithEventsClient(events EventsClient) NodeOption {
+	return func(n *Node) {
+		n.events = events
+	}
+}
+
 type Node struct {
-	Client *http.HTTP
+	Client      *http.HTTP
+	events      EventsClient
+	queryClient rpcclient.Client // Client, or a cached client for module queries
+
+	breaker *CircuitBreaker
 
 	// Save endpoint url for redacted logging
 	endpoint *url.URL
 
 	disableWebsocket bool
 
+	backfillDepth       int64
+	backfillConcurrency int
+	startHeight         int64
+
 	onStart  []OnNodeStart
 	onStatus []OnNodeStatus
 	onEvent  map[string][]OnNodeEvent
 
-	chainID       string
+	chainID         string
+	expectedChainID string
+	quarantineMu    sync.RWMutex
+	quarantine      map[string]string // quarantine details by reason
+	divergences     map[string]*Divergence
+	divergence      *divergenceTracker
+	pool            *Pool
+
 	status        atomic.Value
+	statusTime    atomic.Int64 // time of the latest status sync (unix ns)
+	abciInfo      atomic.Value
 	latestBlock   atomic.Value
 	started       chan struct{}
 	startedOnce   sync.Once
@@ -61,11 +109,18 @@ func NewNode(client *http.HTTP, options ...NodeOption) *Node {
 
 	node := &Node{
 		Client:        client,
+		events:        client,
+		queryClient:   client,
 		endpoint:      endpoint,
 		started:       make(chan struct{}),
 		startedOnce:   sync.Once{},
 		subscriptions: make(map[string]<-chan ctypes.ResultEvent),
 		onEvent:       make(map[string][]OnNodeEvent),
+		quarantine:    make(map[string]string),
+		divergences:   make(map[string]*Divergence),
+
+		backfillDepth:       defaultBackfillDepth,
+		backfillConcurrency: defaultBackfillConcurrency,
 	}
 
 	for _, opt := range options {
@@ -79,18 +134,29 @@ func (n *Node) Endpoint() string {
 	if n.endpoint == nil {
 		return n.Client.Remote()
 	}
-	ep := *n.endpoint
-	if _, has := ep.User.Password(); has {
-		ep.User = nil
-	}
+	return endpointLabel(n.endpoint)
+}
+
+// endpointLabel returns the endpoint without credentials (used in metrics labels).
+//
+// The whole userinfo is dropped, as some providers use a token as username.
+func endpointLabel(u *url.URL) string {
+	ep := *u
+	ep.User = nil
 	return ep.String()
 }
 
+// QueryClient returns the client to run the module queries with (through the
+// query cache if enabled).
+func (n *Node) QueryClient() rpcclient.Client {
+	return n.queryClient
+}
+
 func (n *Node) Redacted() string {
 	if n.endpoint == nil {
 		return n.Client.Remote()
 	}
-	return n.endpoint.Redacted()
+	return endpointLabel(n.endpoint)
 }
 
 func (n *Node) OnStart(callback OnNodeStart) {
@@ -110,7 +176,7 @@ func (n *Node) Started() chan struct{} {
 }
 
 func (n *Node) IsRunning() bool {
-	return n.Client.IsRunning()
+	return n.events.IsRunning()
 }
 
 func (n *Node) IsSynced() bool {
@@ -123,6 +189,12 @@ func (n *Node) IsSynced() bool {
 		status.SyncInfo.LatestBlockTime.After(time.Now().Add(-120*time.Second))
 }
 
+// IsCircuitOpen reports if the node is taken out of use by its circuit breaker
+// (open or half-open).
+func (n *Node) IsCircuitOpen() bool {
+	return n.breaker != nil && n.breaker.State() != BreakerClosed
+}
+
 func (n *Node) ChainID() string {
 	return n.chainID
 }
@@ -137,7 +209,7 @@ func (n *Node) Start(ctx context.Context) error {
 		if err != nil {
 			log.Error().Err(err).Msg("failed to sync status")
 		}
-		if status != nil && !status.SyncInfo.CatchingUp {
+		if status != nil && !status.SyncInfo.CatchingUp && !n.IsQuarantined() {
 			break
 		}
 
@@ -152,7 +224,7 @@ func (n *Node) Start(ctx context.Context) error {
 
 	// Start the websocket process
 	if !n.disableWebsocket {
-		if err := n.Client.Start(); err != nil {
+		if err := n.events.Start(); err != nil {
 			return fmt.Errorf("failed to start client: %w", err)
 		}
 	}
@@ -173,8 +245,11 @@ func (n *Node) Start(ctx context.Context) error {
 		n.handleStart(ctx)
 	})
 
+	// Catch up with the latest blocks (since the start height if any)
+	n.syncBlocks(ctx)
+
 	// Start the status loop
-	statusTicker := time.NewTicker(30 * time.Second)
+	statusTicker := time.NewTicker(statusInterval)
 	blocksTicker := time.NewTicker(10 * time.Second)
 	for {
 		select {
@@ -185,7 +260,7 @@ func (n *Node) Start(ctx context.Context) error {
 		case evt := <-blocksEvents:
 			log.Debug().Msg("got new block event")
 			n.saveLatestBlock(evt.Data.(types.EventDataNewBlock).Block)
-			n.handleEvent(ctx, EventNewBlock, &evt)
+			n.dispatchBlock(ctx, evt.Data.(types.EventDataNewBlock).Block, &evt)
 			blocksTicker.Reset(10 * time.Second)
 
 		case evt := <-validatorEvents:
@@ -193,6 +268,9 @@ func (n *Node) Start(ctx context.Context) error {
 			n.handleEvent(ctx, EventValidatorSetUpdates, &evt)
 
 		case <-blocksTicker.C:
+			if n.IsQuarantined() {
+				continue
+			}
 			log.Debug().Msg("syncing latest blocks")
 			n.syncBlocks(ctx)
 
@@ -238,6 +316,7 @@ func (n *Node) syncStatus(ctx context.Context) (*ctypes.ResultStatus, error) {
 	}, retryOpts...)
 
 	n.status.Store(status)
+	n.statusTime.Store(time.Now().UnixNano())
 
 	if err != nil {
 		return status, fmt.Errorf("failed to get status of %s: %w", n.Redacted(), err)
@@ -250,6 +329,9 @@ func (n *Node) syncStatus(ctx context.Context) (*ctypes.ResultStatus, error) {
 	}
 
 	n.chainID = status.NodeInfo.Network
+	n.checkChainID(status.NodeInfo.Network)
+	n.syncABCIInfo(ctx)
+	n.reportStatus(ctx, status)
 
 	for _, onStatus := range n.onStatus {
 		if err := onStatus(ctx, n, status); err != nil {
@@ -260,6 +342,34 @@ func (n *Node) syncStatus(ctx context.Context) (*ctypes.ResultStatus, error) {
 	return status, nil
 }
 
+// refreshStatus syncs the status if it was not synced for a status interval.
+func (n *Node) refreshStatus(ctx context.Context) {
+	if time.Since(time.Unix(0, n.statusTime.Load())) < statusInterval {
+		return
+	}
+	n.syncStatus(ctx)
+}
+
+// ABCIInfo returns the application info fetched with the latest status.
+func (n *Node) ABCIInfo() *ctypes.ResultABCIInfo {
+	abciInfo := n.abciInfo.Load()
+	if abciInfo == nil {
+		return nil
+	}
+	return abciInfo.(*ctypes.ResultABCIInfo)
+}
+
+func (n *Node) syncABCIInfo(ctx context.Context) {
+	abciInfo, err := n.Client.ABCIInfo(ctx)
+	if err != nil {
+		log.Warn().Err(err).Str("node", n.Redacted()).Msg("failed to get abci info")
+		return
+	}
+
+	n.abciInfo.Store(abciInfo)
+	n.reportStateHash(ctx, abciInfo.Response.LastBlockHeight, abciInfo.Response.LastBlockAppHash)
+}
+
 func (n *Node) handleStart(ctx context.Context) {
 	log := log.With().Str("node", n.Redacted()).Logger()
 
@@ -275,6 +385,11 @@ func (n *Node) handleStart(ctx context.Context) {
 }
 
 func (n *Node) handleEvent(ctx context.Context, eventType string, event *ctypes.ResultEvent) {
+	// Events of quarantined nodes are not forwarded to watchers
+	if n.IsQuarantined() {
+		return
+	}
+
 	for _, onEvent := range n.onEvent[eventType] {
 		if err := onEvent(ctx, n, event); err != nil {
 			log.Error().Err(err).Msgf("failed to call event callback")
@@ -317,36 +432,23 @@ func (n *Node) syncBlocks(ctx context.Context) {
 		latestBlockHeight = latestBlock.Height
 	}
 
-	// Go back to a maximum of 20 blocks
-	if currentBlock.Height-latestBlockHeight > 20 {
-		latestBlockHeight = currentBlock.Height - 20
+	if latestBlock == nil && n.startHeight > 0 {
+		// Resume from the start height
+		latestBlockHeight = n.startHeight - 1
+	} else if currentBlock.Height-latestBlockHeight > n.backfillDepth {
+		// Go back to a maximum of `backfillDepth` blocks
+		latestBlockHeight = currentBlock.Height - n.backfillDepth
 	}
 
 	// Fetch all skipped blocks since latest known block
-	for height := latestBlockHeight + 1; height < currentBlock.Height; height++ {
-		blockResp, err := n.Client.Block(ctx, &height)
-		if err != nil {
-			log.Error().Err(err).Msgf("failed to sync with latest block")
-			continue
-		}
-
-		n.handleEvent(ctx, EventNewBlock, &ctypes.ResultEvent{
-			Query: "",
-			Data: types.EventDataNewBlock{
-				Block: blockResp.Block,
-			},
-			Events: make(map[string][]string),
-		})
-	}
-
-	n.handleEvent(ctx, EventNewBlock, &ctypes.ResultEvent{
-		Query: "",
-		Data: types.EventDataNewBlock{
-			Block: currentBlockResp.Block,
-		},
-		Events: make(map[string][]string),
+	n.FetchBlocks(ctx, latestBlockHeight+1, currentBlock.Height-1, func(block *types.Block) {
+		// The status loop is paused during the backfill, keep the node synced
+		n.refreshStatus(ctx)
+		n.dispatchBlock(ctx, block, newBlockEvent(block))
 	})
 
+	n.dispatchBlock(ctx, currentBlock, newBlockEvent(currentBlockResp.Block))
+
 	n.saveLatestBlock(currentBlockResp.Block)
 }
 
@@ -355,13 +457,9 @@ func (n *Node) Stop(ctx context.Context) error {
 		return nil
 	}
 
-	// if err := n.Client.UnsubscribeAll(ctx, "cosmos-validator-watcher"); err != nil {
-	// 	return fmt.Errorf("failed to unsubscribe from all events: %w", err)
-	// }
-
-	// if err := n.Client.Stop(); err != nil {
-	// 	return fmt.Errorf("failed to stop client: %w", err)
-	// }
+	if err := n.events.Stop(); err != nil {
+		return fmt.Errorf("failed to stop events client: %w", err)
+	}
 
 	return nil
 }
@@ -375,7 +473,7 @@ func (n *Node) Subscribe(ctx context.Context, eventType string) (<-chan ctypes.R
 		return res, nil
 	}
 
-	out, err := n.Client.Subscribe(ctx, "cosmos-validator-watcher", fmt.Sprintf("tm.event='%s'", eventType), 10)
+	out, err := n.events.Subscribe(ctx, "cosmos-validator-watcher", fmt.Sprintf("tm.event='%s'", eventType), 10)
 	if err != nil {
 		return nil, fmt.Errorf("failed to subscribe to %s: %w", eventType, err)
 	}
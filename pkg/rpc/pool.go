@@ -13,17 +13,42 @@ type Pool struct {
 	ChainID string
 	Nodes   []*Node
 
+	divergence  *divergenceTracker
+	maxLag      int64
 	started     chan struct{}
 	startedOnce sync.Once
 }
 
-func NewPool(chainID string, nodes []*Node) *Pool {
-	return &Pool{
+func NewPool(chainID string, nodes []*Node, options ...PoolOption) *Pool {
+	divergence := newDivergenceTracker()
+	divergence.nodes = nodes
+	pool := &Pool{
 		ChainID:     chainID,
 		Nodes:       nodes,
+		divergence:  divergence,
 		started:     make(chan struct{}),
 		startedOnce: sync.Once{},
 	}
+
+	for _, opt := range options {
+		opt(pool)
+	}
+
+	// Quarantine nodes which are not on the expected chain
+	for _, node := range nodes {
+		node.expectedChainID = chainID
+		node.checkChainID(node.ChainID())
+		node.divergence = divergence
+		node.pool = pool
+	}
+
+	return pool
+}
+
+// OnNodeDivergence registers a callback called each time a node starts or
+// stops diverging from the other nodes of the pool.
+func (p *Pool) OnNodeDivergence(callback OnNodeDivergence) {
+	p.divergence.onDivergence = append(p.divergence.onDivergence, callback)
 }
 
 func (p *Pool) Start(ctx context.Context) error {
@@ -76,7 +101,7 @@ func (p *Pool) Started() chan struct{} {
 
 func (p *Pool) GetSyncedNode() *Node {
 	for _, node := range p.Nodes {
-		if node.IsSynced() {
+		if node.IsSynced() && !node.IsQuarantined() && !p.IsLagging(node) && !node.IsCircuitOpen() {
 			return node
 		}
 	}
@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"sort"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	QuarantineChainIDMismatch = "chain_id_mismatch"
+	QuarantineForked          = "forked"
+	QuarantineAppHashMismatch = "app_hash_mismatch"
+	QuarantineStale           = "stale"
+)
+
+// QuarantineReasons lists all the reasons a node can be quarantined for.
+var QuarantineReasons = []string{
+	QuarantineChainIDMismatch,
+	QuarantineForked,
+	QuarantineAppHashMismatch,
+	QuarantineStale,
+}
+
+// Quarantine excludes the node from the pool (synced node selection & events)
+// until it is released.
+func (n *Node) Quarantine(reason string, detail string) {
+	n.quarantineMu.Lock()
+	defer n.quarantineMu.Unlock()
+
+	if _, ok := n.quarantine[reason]; !ok {
+		log.Warn().
+			Str("node", n.Redacted()).
+			Str("reason", reason).
+			Msgf("node quarantined: %s", detail)
+	}
+
+	n.quarantine[reason] = detail
+}
+
+// Release removes the node from quarantine for the given reason.
+func (n *Node) Release(reason string) {
+	n.quarantineMu.Lock()
+	defer n.quarantineMu.Unlock()
+
+	if _, ok := n.quarantine[reason]; !ok {
+		return
+	}
+
+	log.Info().
+		Str("node", n.Redacted()).
+		Str("reason", reason).
+		Msg("node released from quarantine")
+
+	delete(n.quarantine, reason)
+}
+
+func (n *Node) IsQuarantined() bool {
+	n.quarantineMu.RLock()
+	defer n.quarantineMu.RUnlock()
+
+	return len(n.quarantine) > 0
+}
+
+// isQuarantinedFor reports if the node is quarantined for the given reason.
+func (n *Node) isQuarantinedFor(reason string) bool {
+	n.quarantineMu.RLock()
+	defer n.quarantineMu.RUnlock()
+
+	_, ok := n.quarantine[reason]
+	return ok
+}
+
+// QuarantineReasons returns the reasons the node is currently quarantined for.
+func (n *Node) QuarantineReasons() []string {
+	n.quarantineMu.RLock()
+	defer n.quarantineMu.RUnlock()
+
+	reasons := make([]string, 0, len(n.quarantine))
+	for reason := range n.quarantine {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	return reasons
+}
+
+// checkChainID quarantines the node if it is not on the expected network.
+func (n *Node) checkChainID(network string) {
+	if n.expectedChainID == "" || network == "" {
+		return
+	}
+
+	if network != n.expectedChainID {
+		n.Quarantine(QuarantineChainIDMismatch, "node is on "+network+" instead of "+n.expectedChainID)
+	} else {
+		n.Release(QuarantineChainIDMismatch)
+	}
+}
@@ -0,0 +1,25 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/cometbft/cometbft/rpc/client/http"
+	"gotest.tools/assert"
+)
+
+func TestQuarantine(t *testing.T) {
+	client, err := http.New("http://localhost:26657", "/websocket")
+	assert.NilError(t, err)
+
+	node := NewNode(client)
+	node.chainID = "chain-43"
+
+	pool := NewPool("chain-42", []*Node{node})
+	assert.Equal(t, true, node.IsQuarantined())
+	assert.DeepEqual(t, []string{QuarantineChainIDMismatch}, node.QuarantineReasons())
+	assert.Equal(t, true, pool.GetSyncedNode() == nil)
+
+	node.checkChainID("chain-42")
+	assert.Equal(t, false, node.IsQuarantined())
+	assert.DeepEqual(t, []string{}, node.QuarantineReasons())
+}
@@ -0,0 +1,73 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cometbft/cometbft/types"
+	"github.com/rs/zerolog/log"
+)
+
+// Replay feeds the blocks of a historical height range to the callbacks of
+// the first synced node (as if they were received live), then returns.
+func (p *Pool) Replay(ctx context.Context, from, to int64) error {
+	node := p.GetSyncedNode()
+	if node == nil {
+		return fmt.Errorf("no node available to replay blocks")
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-node.Started():
+			p.startedOnce.Do(func() {
+				close(p.started)
+			})
+		}
+	}()
+
+	return node.Replay(ctx, from, to)
+}
+
+// Replay calls the start callbacks, then the new block callbacks for each
+// block of the range (a zero `to` height replays up to the latest block).
+func (n *Node) Replay(ctx context.Context, from, to int64) error {
+	status, err := n.syncStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to sync status: %w", err)
+	}
+
+	earliest, latest := status.SyncInfo.EarliestBlockHeight, status.SyncInfo.LatestBlockHeight
+	if to <= 0 || to > latest {
+		to = latest
+	}
+	if from < earliest {
+		return fmt.Errorf("node does not have blocks before height %d (use an archive node)", earliest)
+	}
+	if from > to {
+		return fmt.Errorf("invalid replay range: %d to %d", from, to)
+	}
+
+	n.startedOnce.Do(func() {
+		n.handleStart(ctx)
+	})
+
+	log.Info().Str("node", n.Redacted()).Int64("from", from).Int64("to", to).Msg("replaying blocks")
+
+	n.FetchBlocks(ctx, from, to, func(block *types.Block) {
+		n.handleEvent(ctx, EventNewBlock, newBlockEvent(block))
+	})
+
+	return ctx.Err()
+}
+
+func newBlockEvent(block *types.Block) *ctypes.ResultEvent {
+	return &ctypes.ResultEvent{
+		Query: "",
+		Data: types.EventDataNewBlock{
+			Block: block,
+		},
+		Events: make(map[string][]string),
+	}
+}
@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cometbft/cometbft/types"
+	"gotest.tools/assert"
+)
+
+func TestReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params struct {
+				Height string `json:"height"`
+			} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch req.Method {
+		case "status":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"node_info":{"network":"chain-42"},"sync_info":{"latest_block_height":"100","earliest_block_height":"50","catching_up":false}}}`, req.ID)
+		case "block":
+			height, _ := strconv.ParseInt(req.Params.Height, 10, 64)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"block_id":{"hash":"","parts":{"total":0,"hash":""}},"block":{"header":{"height":"%d"},"data":{"txs":[]},"evidence":{"evidence":[]},"last_commit":null}}}`, req.ID, height)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"Method not found"}}`, req.ID)
+		}
+	}))
+	defer server.Close()
+
+	replay := func(from, to int64) ([]int64, bool, error) {
+		client, err := NewClient(server.URL, ClientOptions{})
+		assert.NilError(t, err)
+
+		var (
+			heights = []int64{}
+			started = false
+		)
+		node := NewNode(client, BackfillConcurrency(3))
+		node.OnStart(func(ctx context.Context, n *Node) error {
+			started = true
+			return nil
+		})
+		node.OnEvent(EventNewBlock, func(ctx context.Context, n *Node, event *ctypes.ResultEvent) error {
+			heights = append(heights, event.Data.(types.EventDataNewBlock).Block.Height)
+			return nil
+		})
+
+		err = node.Replay(context.Background(), from, to)
+		return heights, started, err
+	}
+
+	t.Run("Height Range", func(t *testing.T) {
+		heights, started, err := replay(60, 64)
+		assert.NilError(t, err)
+		assert.Equal(t, true, started)
+		assert.DeepEqual(t, []int64{60, 61, 62, 63, 64}, heights)
+	})
+
+	t.Run("Up To Latest", func(t *testing.T) {
+		heights, _, err := replay(98, 0)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []int64{98, 99, 100}, heights)
+	})
+
+	t.Run("Pruned Heights", func(t *testing.T) {
+		_, started, err := replay(10, 20)
+		assert.ErrorContains(t, err, "does not have blocks before height 50")
+		assert.Equal(t, false, started)
+	})
+}
@@ -0,0 +1,121 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+)
+
+// instrumentedTransport measures the duration, errors & in-flight requests
+// sent to a node, labelled by JSON-RPC method (or ABCI query path).
+type instrumentedTransport struct {
+	next     http.RoundTripper
+	metrics  *metrics.Metrics
+	endpoint string
+}
+
+func newInstrumentedTransport(next http.RoundTripper, metrics *metrics.Metrics, endpoint string) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &instrumentedTransport{
+		next:     next,
+		metrics:  metrics,
+		endpoint: endpoint,
+	}
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	method := requestMethod(req)
+
+	inFlight := t.metrics.RPCRequestsInFlight.WithLabelValues(t.endpoint)
+	inFlight.Inc()
+	defer inFlight.Dec()
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	t.metrics.RPCRequestDuration.WithLabelValues(t.endpoint, method).Observe(time.Since(start).Seconds())
+
+	if err != nil || resp.StatusCode >= http.StatusBadRequest {
+		t.metrics.RPCRequestErrors.WithLabelValues(t.endpoint, method).Inc()
+	}
+
+	return resp, err
+}
+
+type jsonRPCRequest struct {
+	Method string `json:"method"`
+	Params struct {
+		Path string `json:"path"`
+	} `json:"params"`
+}
+
+// requestMethod extracts the JSON-RPC method from the request body, using the
+// query path for ABCI queries (eg. /cosmos.staking.v1beta1.Query/Validators).
+func requestMethod(req *http.Request) string {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "unknown"
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return "unknown"
+	}
+
+	// Batch requests are labelled as a whole
+	if len(bytes.TrimSpace(body)) > 0 && bytes.TrimSpace(body)[0] == '[' {
+		return "batch"
+	}
+
+	var rpcReq jsonRPCRequest
+	if err := json.Unmarshal(body, &rpcReq); err != nil || rpcReq.Method == "" {
+		return "unknown"
+	}
+
+	if rpcReq.Method == "abci_query" && rpcReq.Params.Path != "" {
+		return rpcReq.Params.Path
+	}
+
+	return rpcReq.Method
+}
+
+// guardedTransport applies the rate limit & circuit breaker of a node.
+type guardedTransport struct {
+	next    http.RoundTripper
+	limiter *RateLimiter
+	breaker *CircuitBreaker
+}
+
+func (t *guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.limiter != nil {
+		if err := t.limiter.Wait(req.Context()); err != nil {
+			return nil, fmt.Errorf("rate limit: %w", err)
+		}
+	}
+
+	if t.breaker == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	if !t.breaker.Allow(time.Now()) {
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if req.Context().Err() != nil {
+		// Canceled by the caller, not a node failure
+		t.breaker.Release()
+		return resp, err
+	}
+
+	t.breaker.Record(err == nil && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < http.StatusInternalServerError, time.Now())
+
+	return resp, err
+}
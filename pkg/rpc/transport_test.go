@@ -0,0 +1,37 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestInstrumentedTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":-1,"error":{"code":-32603,"message":"internal error"}}`))
+	}))
+	defer server.Close()
+
+	m := metrics.New("cosmos_validator_watcher")
+	client, err := NewClient(server.URL, ClientOptions{Metrics: m})
+	assert.NilError(t, err)
+
+	ctx := context.Background()
+	client.Status(ctx)
+	client.ABCIQuery(ctx, "/cosmos.staking.v1beta1.Query/Validators", nil)
+	client.ABCIQuery(ctx, "/cosmos.staking.v1beta1.Query/Validators", nil)
+
+	assert.Equal(t, 2, testutil.CollectAndCount(m.RPCRequestDuration))
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.RPCRequestsInFlight.WithLabelValues(server.URL)))
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.RPCRequestErrors.WithLabelValues(server.URL, "status")))
+
+	server.Close()
+	client.Status(ctx)
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.RPCRequestErrors.WithLabelValues(server.URL, "status")))
+}
@@ -0,0 +1,314 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cmtjson "github.com/cometbft/cometbft/libs/json"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	rpctypes "github.com/cometbft/cometbft/rpc/jsonrpc/types"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	wsReconnectDelay = 5 * time.Second
+	wsReadTimeout    = time.Minute
+	wsWriteTimeout   = 10 * time.Second
+)
+
+// EventsClient subscribes to the events of a node.
+//
+// It is implemented by the CometBFT client, and by WSEvents for nodes which
+// require custom headers, TLS or proxy settings.
+type EventsClient interface {
+	Start() error
+	Stop() error
+	IsRunning() bool
+	Subscribe(ctx context.Context, subscriber, query string, outCapacity ...int) (<-chan ctypes.ResultEvent, error)
+}
+
+// WSEvents is a websocket events client honoring the client options.
+//
+// The connection is kept open and subscriptions are restored after reconnecting.
+type WSEvents struct {
+	url     string
+	dialer  *websocket.Dialer
+	headers http.Header
+	log     zerolog.Logger
+
+	running  atomic.Bool
+	nextID   atomic.Int64
+	done     chan struct{} // closed to stop the read loop
+	loopDone chan struct{} // closed once the read loop ended
+	stopOnce sync.Once
+
+	mu            sync.RWMutex
+	conn          *websocket.Conn
+	writeMu       sync.Mutex
+	subscriptions map[string]chan ctypes.ResultEvent // query -> chan
+}
+
+func NewWSEvents(endpoint string, options ClientOptions) (*WSEvents, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse endpoint: %w", err)
+	}
+	redacted := endpointLabel(u)
+
+	switch u.Scheme {
+	case "https", "wss":
+		u.Scheme = "wss"
+	case "http", "ws", "tcp", "":
+		u.Scheme = "ws"
+	default:
+		return nil, fmt.Errorf("unsupported websocket scheme: %s", u.Scheme)
+	}
+	u.Path = "/websocket"
+	u.RawQuery = ""
+
+	tlsConfig, err := options.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	headers := options.headers()
+
+	// Basic auth credentials in the endpoint are sent as a header
+	if u.User != nil {
+		password, _ := u.User.Password()
+		req := http.Request{Header: make(http.Header)}
+		req.SetBasicAuth(u.User.Username(), password)
+		headers.Set("Authorization", req.Header.Get("Authorization"))
+		u.User = nil
+	}
+
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		TLSClientConfig:  tlsConfig,
+		HandshakeTimeout: wsWriteTimeout,
+	}
+	if options.ProxyURL != nil {
+		dialer.Proxy = http.ProxyURL(options.ProxyURL)
+	}
+
+	return &WSEvents{
+		url:           u.String(),
+		dialer:        dialer,
+		headers:       headers,
+		log:           log.With().Str("node", redacted).Logger(),
+		done:          make(chan struct{}),
+		loopDone:      make(chan struct{}),
+		subscriptions: make(map[string]chan ctypes.ResultEvent),
+	}, nil
+}
+
+func (w *WSEvents) Start() error {
+	if err := w.connect(); err != nil {
+		return err
+	}
+
+	w.running.Store(true)
+	go w.readLoop()
+
+	return nil
+}
+
+// Stop closes the connection and waits for the read loop to end (including
+// the reconnection attempts).
+func (w *WSEvents) Stop() error {
+	w.stopOnce.Do(func() {
+		close(w.done)
+		if !w.running.Swap(false) {
+			return
+		}
+
+		w.mu.RLock()
+		w.conn.Close()
+		w.mu.RUnlock()
+		<-w.loopDone
+	})
+
+	return nil
+}
+
+func (w *WSEvents) IsRunning() bool {
+	return w.running.Load()
+}
+
+func (w *WSEvents) Subscribe(ctx context.Context, _ string, query string, outCapacity ...int) (<-chan ctypes.ResultEvent, error) {
+	if !w.IsRunning() {
+		return nil, fmt.Errorf("websocket client is not running")
+	}
+
+	capacity := 1
+	if len(outCapacity) > 0 {
+		capacity = outCapacity[0]
+	}
+
+	w.mu.Lock()
+	out, ok := w.subscriptions[query]
+	if !ok {
+		out = make(chan ctypes.ResultEvent, capacity)
+		w.subscriptions[query] = out
+	}
+	w.mu.Unlock()
+
+	if err := w.subscribe(query); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func (w *WSEvents) connect() error {
+	conn, _, err := w.dialer.Dial(w.url, w.headers) //nolint:bodyclose
+	if err != nil {
+		return fmt.Errorf("failed to dial websocket: %w", err)
+	}
+
+	// The node pings its clients periodically, extend the deadline on each ping
+	conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(wsReadTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(wsWriteTimeout))
+	})
+
+	w.mu.Lock()
+	w.conn = conn
+	w.mu.Unlock()
+
+	return nil
+}
+
+func (w *WSEvents) subscribe(query string) error {
+	params, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		return err
+	}
+	request := rpctypes.NewRPCRequest(rpctypes.JSONRPCIntID(w.nextID.Add(1)), "subscribe", params)
+
+	w.mu.RLock()
+	conn := w.conn
+	w.mu.RUnlock()
+
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := conn.WriteJSON(request); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", query, err)
+	}
+
+	return nil
+}
+
+func (w *WSEvents) readLoop() {
+	defer close(w.loopDone)
+
+	for {
+		w.mu.RLock()
+		conn := w.conn
+		w.mu.RUnlock()
+
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			conn.Close()
+			if w.stopped() {
+				return
+			}
+			w.log.Warn().Err(err).Msg("websocket connection lost, reconnecting")
+			if !w.reconnect() {
+				return
+			}
+			continue
+		}
+
+		w.handleMessage(data)
+	}
+}
+
+// stopped reports if the client was stopped.
+func (w *WSEvents) stopped() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// reconnect reconnects until it succeeds or the client is stopped (returns false).
+func (w *WSEvents) reconnect() bool {
+	for {
+		select {
+		case <-w.done:
+			return false
+		case <-time.After(wsReconnectDelay):
+		}
+
+		if err := w.connect(); err != nil {
+			w.log.Warn().Err(err).Msg("failed to reconnect websocket")
+			continue
+		}
+
+		// Stopped while reconnecting
+		if w.stopped() {
+			w.mu.RLock()
+			w.conn.Close()
+			w.mu.RUnlock()
+			return false
+		}
+
+		w.mu.RLock()
+		queries := make([]string, 0, len(w.subscriptions))
+		for query := range w.subscriptions {
+			queries = append(queries, query)
+		}
+		w.mu.RUnlock()
+
+		for _, query := range queries {
+			if err := w.subscribe(query); err != nil {
+				w.log.Warn().Err(err).Msg("failed to resubscribe")
+			}
+		}
+		return true
+	}
+}
+
+func (w *WSEvents) handleMessage(data []byte) {
+	var resp rpctypes.RPCResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		w.log.Warn().Err(err).Msg("failed to unmarshal websocket response")
+		return
+	}
+
+	if resp.Error != nil {
+		w.log.Warn().Str("error", resp.Error.Error()).Msg("websocket error")
+		return
+	}
+
+	result := new(ctypes.ResultEvent)
+	if err := cmtjson.Unmarshal(resp.Result, result); err != nil {
+		w.log.Warn().Err(err).Msg("failed to unmarshal websocket event")
+		return
+	}
+
+	// Subscription acknowledgements have an empty result
+	w.mu.RLock()
+	out, ok := w.subscriptions[result.Query]
+	w.mu.RUnlock()
+	if ok {
+		select {
+		case out <- *result:
+		case <-w.done:
+		}
+	}
+}
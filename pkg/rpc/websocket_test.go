@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"gotest.tools/assert"
+)
+
+func TestWSEvents(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// Acknowledge the subscription & send an event
+		var req map[string]any
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"result":{"query":"tm.event='NewBlock'","data":{"type":"tendermint/event/NewBlock","value":{"block":{"header":{"height":"42"}}}},"events":{}}}`))
+
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	events, err := NewWSEvents(server.URL, ClientOptions{BearerToken: "token"})
+	assert.NilError(t, err)
+	assert.NilError(t, events.Start())
+	assert.Equal(t, true, events.IsRunning())
+
+	out, err := events.Subscribe(context.Background(), "", "tm.event='NewBlock'", 10)
+	assert.NilError(t, err)
+
+	select {
+	case evt := <-out:
+		assert.Equal(t, "tm.event='NewBlock'", evt.Query)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+	}
+
+	// Stopping ends the read loop
+	assert.NilError(t, events.Stop())
+	assert.Equal(t, false, events.IsRunning())
+
+	// Missing credentials
+	events, err = NewWSEvents(server.URL, ClientOptions{})
+	assert.NilError(t, err)
+	assert.ErrorContains(t, events.Start(), "failed to dial websocket")
+}
+
+func TestWSEventsStop(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The connection is dropped right away
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}))
+	defer server.Close()
+
+	events, err := NewWSEvents(server.URL, ClientOptions{})
+	assert.NilError(t, err)
+	assert.NilError(t, events.Start())
+
+	// Stop while waiting to reconnect
+	time.Sleep(100 * time.Millisecond)
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- events.Stop()
+	}()
+
+	select {
+	case err := <-stopped:
+		assert.NilError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("websocket client not stopped")
+	}
+	assert.Equal(t, false, events.IsRunning())
+}
@@ -0,0 +1,109 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+const wsWriteTimeout = 10 * time.Second
+
+// SSEHandler streams the events as Server-Sent Events.
+func (h *Hub) SSEHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		events, unsubscribe := h.Subscribe(parseTypes(r.URL.Query().Get("types"))...)
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-h.done:
+				return
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			case event := <-events:
+				data, err := json.Marshal(event)
+				if err != nil {
+					log.Error().Err(err).Msg("failed to encode stream event")
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// WebSocketHandler streams the events as JSON messages over a websocket.
+func (h *Hub) WebSocketHandler() http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		// Read-only stream, accept browsers from any origin
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Debug().Err(err).Msg("failed to upgrade stream connection")
+			return
+		}
+		defer conn.Close()
+
+		events, unsubscribe := h.Subscribe(parseTypes(r.URL.Query().Get("types"))...)
+		defer unsubscribe()
+
+		// Discard incoming messages, stop when the client disconnects
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case <-h.done:
+				return
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+					return
+				}
+			case event := <-events:
+				conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+				if err := conn.WriteJSON(event); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package stream
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	EventBlock             = "block"
+	EventBabylonFinality   = "babylon_finality"
+	EventBabylonCheckpoint = "babylon_checkpoint"
+
+	// Events buffered for each subscriber (events are dropped for slow subscribers)
+	subscriberBuffer = 64
+
+	// Interval of the keep-alive messages sent to the subscribers
+	keepAliveInterval = 30 * time.Second
+)
+
+// Event is a watcher result published to the stream subscribers.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data"`
+}
+
+// Hub broadcasts the published events to all the subscribers.
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[chan Event][]string // event types filter (empty for all)
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[chan Event][]string),
+		done:        make(chan struct{}),
+	}
+}
+
+// Publish sends an event to the subscribers without blocking.
+func (h *Hub) Publish(eventType string, data any) {
+	event := Event{Type: eventType, Time: time.Now(), Data: data}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch, types := range h.subscribers {
+		if len(types) > 0 && !slices.Contains(types, eventType) {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+			// Drop the event for slow subscribers
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the events of the given types (all
+// events if none) and a function to unsubscribe.
+func (h *Hub) Subscribe(types ...string) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	h.subscribers[ch] = types
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subscribers, ch)
+		h.mu.Unlock()
+	}
+}
+
+// Close ends the streams of all the subscribers (eg. before shutting down the HTTP server).
+func (h *Hub) Close() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
+// Subscribers returns the number of active subscribers.
+func (h *Hub) Subscribers() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.subscribers)
+}
+
+// parseTypes parses a comma-separated list of event types (eg. ?types=block,babylon_finality).
+func parseTypes(value string) []string {
+	types := []string{}
+	for _, t := range strings.Split(value, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
@@ -0,0 +1,114 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"gotest.tools/assert"
+)
+
+func TestHub(t *testing.T) {
+	t.Run("Publish & Subscribe", func(t *testing.T) {
+		hub := NewHub()
+
+		all, unsubscribeAll := hub.Subscribe()
+		blocks, unsubscribeBlocks := hub.Subscribe(EventBlock)
+		assert.Equal(t, 2, hub.Subscribers())
+
+		hub.Publish(EventBabylonFinality, 41)
+		hub.Publish(EventBlock, 42)
+
+		assert.Equal(t, EventBabylonFinality, (<-all).Type)
+		assert.Equal(t, EventBlock, (<-all).Type)
+		assert.Equal(t, 42, (<-blocks).Data)
+		assert.Equal(t, 0, len(blocks))
+
+		unsubscribeAll()
+		unsubscribeBlocks()
+		assert.Equal(t, 0, hub.Subscribers())
+	})
+
+	t.Run("Drop Events Of Slow Subscribers", func(t *testing.T) {
+		hub := NewHub()
+
+		events, unsubscribe := hub.Subscribe()
+		defer unsubscribe()
+
+		for i := 0; i < subscriberBuffer+10; i++ {
+			hub.Publish(EventBlock, i)
+		}
+		assert.Equal(t, subscriberBuffer, len(events))
+	})
+
+	t.Run("Parse Types", func(t *testing.T) {
+		assert.DeepEqual(t, []string{}, parseTypes(""))
+		assert.DeepEqual(t, []string{EventBlock, EventBabylonFinality}, parseTypes("block, babylon_finality,"))
+	})
+}
+
+func TestHandlers(t *testing.T) {
+	t.Run("Server-Sent Events", func(t *testing.T) {
+		hub := NewHub()
+		server := httptest.NewServer(hub.SSEHandler())
+		defer server.Close()
+		defer hub.Close()
+
+		resp, err := server.Client().Get(server.URL + "?types=block")
+		assert.NilError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		waitSubscribers(t, hub, 1)
+		hub.Publish(EventBabylonCheckpoint, 41)
+		hub.Publish(EventBlock, 42)
+
+		reader := bufio.NewReader(resp.Body)
+		line, err := reader.ReadString('\n')
+		assert.NilError(t, err)
+		assert.Equal(t, "event: block\n", line)
+
+		line, err = reader.ReadString('\n')
+		assert.NilError(t, err)
+		var event Event
+		assert.NilError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event))
+		assert.Equal(t, EventBlock, event.Type)
+		assert.Equal(t, float64(42), event.Data)
+	})
+
+	t.Run("WebSocket", func(t *testing.T) {
+		hub := NewHub()
+		server := httptest.NewServer(hub.WebSocketHandler())
+		defer server.Close()
+		defer hub.Close()
+
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil) //nolint:bodyclose
+		assert.NilError(t, err)
+		defer conn.Close()
+
+		waitSubscribers(t, hub, 1)
+		hub.Publish(EventBabylonFinality, 42)
+
+		var event Event
+		assert.NilError(t, conn.ReadJSON(&event))
+		assert.Equal(t, EventBabylonFinality, event.Type)
+		assert.Equal(t, float64(42), event.Data)
+
+		// Subscriber is removed once the client disconnects
+		conn.Close()
+		waitSubscribers(t, hub, 0)
+	})
+}
+
+func waitSubscribers(t *testing.T, hub *Hub, count int) {
+	t.Helper()
+
+	for i := 0; i < 100 && hub.Subscribers() != count; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, count, hub.Subscribers())
+}
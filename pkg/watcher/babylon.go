@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 	"time"
 
 	checkpointing "github.com/babylonlabs-io/babylon/x/checkpointing/types"
@@ -29,8 +30,8 @@ import (
 )
 
 type BabylonFinalityProvider struct {
-	Address string
-	Label   string
+	Address string `json:"address"`
+	Label   string `json:"label"`
 }
 
 func ParseBabylonFinalityProvider(val string) BabylonFinalityProvider {
@@ -58,8 +59,45 @@ type BabylonWatcher struct {
 	latestBlockHeight int64
 	protoCodec        *codec.ProtoCodec
 	epochInterval     int64
+	onFinality        []OnBabylonFinality
+	onCheckpoint      []OnBabylonCheckpoint
+
+	mu         sync.RWMutex
+	finality   *BabylonFinality   // latest finality votes
+	checkpoint *BabylonCheckpoint // latest checkpoint votes
+
+	health *Health
+}
+
+// BabylonVote is the vote of a finality provider or a validator.
+type BabylonVote struct {
+	Address string `json:"address"`
+	Name    string `json:"name"`
+	Voted   bool   `json:"voted"`
+}
+
+// BabylonFinality is the result of the finality votes of a block.
+type BabylonFinality struct {
+	ChainID   string        `json:"chain_id"`
+	Height    int64         `json:"height"`
+	Providers int           `json:"providers"` // finality providers which voted
+	Votes     []BabylonVote `json:"votes"`
+}
+
+// BabylonCheckpoint is the result of the checkpoint votes of an epoch.
+type BabylonCheckpoint struct {
+	ChainID         string        `json:"chain_id"`
+	Height          int64         `json:"height"`
+	Epoch           uint64        `json:"epoch"`
+	VotedValidators int           `json:"voted_validators"`
+	TotalValidators int           `json:"total_validators"`
+	Votes           []BabylonVote `json:"votes"`
 }
 
+type OnBabylonFinality func(finality BabylonFinality)
+
+type OnBabylonCheckpoint func(checkpoint BabylonCheckpoint)
+
 func NewBabylonWatcher(validators []TrackedValidator, finalityProviders []BabylonFinalityProvider, pool *rpc.Pool, metrics *metrics.Metrics, writer io.Writer) *BabylonWatcher {
 	// Create a new Protobuf codec to decode babylon messages
 	interfaceRegistry := codectypes.NewInterfaceRegistry()
@@ -77,13 +115,29 @@ func NewBabylonWatcher(validators []TrackedValidator, finalityProviders []Babylo
 		protoCodec:        protoCodec,
 		blockChan:         make(chan *types.Block),
 		epochInterval:     360,
+		health:            NewHealth("babylon", metrics),
 	}
 }
 
+func (w *BabylonWatcher) Health() *Health {
+	return w.health
+}
+
+// OnFinality registers a callback called with the finality votes of each block.
+func (w *BabylonWatcher) OnFinality(callback OnBabylonFinality) {
+	w.onFinality = append(w.onFinality, callback)
+}
+
+// OnCheckpoint registers a callback called with the checkpoint votes of each epoch.
+func (w *BabylonWatcher) OnCheckpoint(callback OnBabylonCheckpoint) {
+	w.onCheckpoint = append(w.onCheckpoint, callback)
+}
+
 func (w *BabylonWatcher) Start(ctx context.Context) error {
 	if err := w.syncEpochParams(ctx); err != nil {
 		return err
 	}
+	w.health.Report(nil)
 
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
@@ -94,10 +148,14 @@ func (w *BabylonWatcher) Start(ctx context.Context) error {
 			return nil
 		case block := <-w.blockChan:
 			w.handleBlock(block)
+			w.health.Processed(block.Height)
 		case <-ticker.C:
-			if err := w.syncEpochParams(ctx); err != nil {
+			err := w.syncEpochParams(ctx)
+			if err != nil {
 				log.Error().Err(err).Msg("failed to sync epoch params")
 			}
+			w.health.Report(err)
+			w.health.Processed(0)
 		}
 	}
 }
@@ -108,8 +166,18 @@ func (w *BabylonWatcher) OnNewBlock(ctx context.Context, node *rpc.Node, evt *ct
 		return nil
 	}
 
+	return w.OnReplayedBlock(ctx, node, evt)
+}
+
+// OnReplayedBlock handles the blocks of a replay, regardless of the node sync status.
+func (w *BabylonWatcher) OnReplayedBlock(ctx context.Context, node *rpc.Node, evt *ctypes.ResultEvent) error {
 	blockEvent := evt.Data.(types.EventDataNewBlock)
-	w.blockChan <- blockEvent.Block
+
+	// Do not wait for a stopped watcher
+	select {
+	case w.blockChan <- blockEvent.Block:
+	case <-ctx.Done():
+	}
 
 	return nil
 }
@@ -124,7 +192,7 @@ func (w *BabylonWatcher) syncEpochParams(ctx context.Context) error {
 	w.metrics.BabylonCheckpointVote.WithLabelValues(chainID).Add(0)
 	w.metrics.BabylonFinalityVotes.WithLabelValues(chainID).Add(0)
 
-	clientCtx := (client.Context{}).WithClient(node.Client)
+	clientCtx := (client.Context{}).WithClient(node.QueryClient())
 	queryClient := epoching.NewQueryClient(clientCtx)
 	resp, err := queryClient.EpochsInfo(ctx, &epoching.QueryEpochsInfoRequest{
 		Pagination: &query.PageRequest{
@@ -211,6 +279,7 @@ func (w *BabylonWatcher) handleMsgAddFinalitySig(chainID string, blockHeight int
 
 	w.metrics.BabylonFinalityVotes.WithLabelValues(chainID).Inc()
 
+	result := BabylonFinality{ChainID: chainID, Height: blockHeight - 1, Providers: len(msgs)}
 	validatorStatus := []string{}
 	for _, fp := range w.finalityProviders {
 		_, hasSigned := lo.Find(msgs, func(msg *finality.MsgAddFinalitySig) bool {
@@ -226,6 +295,7 @@ func (w *BabylonWatcher) handleMsgAddFinalitySig(chainID string, blockHeight int
 			w.metrics.BabylonMissedFinalityVotes.WithLabelValues(chainID, fp.Address, fp.Label).Inc()
 			w.metrics.BabylonConsecutiveMissedFinalityVotes.WithLabelValues(chainID, fp.Address, fp.Label).Inc()
 		}
+		result.Votes = append(result.Votes, BabylonVote{Address: fp.Address, Name: fp.Label, Voted: hasSigned})
 		validatorStatus = append(validatorStatus, fmt.Sprintf("%s %s", icon, fp.Label))
 	}
 
@@ -235,6 +305,14 @@ func (w *BabylonWatcher) handleMsgAddFinalitySig(chainID string, blockHeight int
 		color.MagentaString(fmt.Sprintf("%3d finality prvds", len(msgs))),
 		strings.Join(validatorStatus, " "),
 	)
+
+	w.mu.Lock()
+	w.finality = &result
+	w.mu.Unlock()
+
+	for _, onFinality := range w.onFinality {
+		onFinality(result)
+	}
 }
 
 func (w *BabylonWatcher) findMsgInjectedCheckpoint(txs types.Txs) *checkpointing.MsgInjectedCheckpoint {
@@ -283,6 +361,7 @@ func (w *BabylonWatcher) handleMsgInjectedCheckpoint(chainID string, blockHeight
 	}
 
 	// Check if validator has voted
+	votes := []BabylonVote{}
 	validatorStatus := []string{}
 	for _, val := range w.trackedValidators {
 		_, hasVoted := lo.Find(msg.ExtendedCommitInfo.Votes, func(vote abcitypes.ExtendedVoteInfo) bool {
@@ -298,6 +377,7 @@ func (w *BabylonWatcher) handleMsgInjectedCheckpoint(chainID string, blockHeight
 			w.metrics.BabylonMissedCheckpointVote.WithLabelValues(chainID, val.Address, val.Name).Inc()
 			w.metrics.BabylonConsecutiveMissedCheckpointVote.WithLabelValues(chainID, val.Address, val.Name).Inc()
 		}
+		votes = append(votes, BabylonVote{Address: val.Address, Name: val.Name, Voted: hasVoted})
 		validatorStatus = append(validatorStatus, fmt.Sprintf("%s %s", icon, val.Name))
 	}
 
@@ -311,4 +391,33 @@ func (w *BabylonWatcher) handleMsgInjectedCheckpoint(chainID string, blockHeight
 		color.CyanString(fmt.Sprintf("%3d/%d validators", votedValidators, totalValidators)),
 		strings.Join(validatorStatus, " "),
 	)
+
+	result := BabylonCheckpoint{
+		ChainID:         chainID,
+		Height:          blockHeight,
+		Epoch:           epoch,
+		VotedValidators: votedValidators,
+		TotalValidators: totalValidators,
+		Votes:           votes,
+	}
+
+	w.mu.Lock()
+	w.checkpoint = &result
+	w.mu.Unlock()
+
+	for _, onCheckpoint := range w.onCheckpoint {
+		onCheckpoint(result)
+	}
+}
+
+// State returns the latest finality & checkpoint votes.
+func (w *BabylonWatcher) State() BabylonState {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return BabylonState{
+		FinalityProviders: w.finalityProviders,
+		Finality:          w.finality,
+		Checkpoint:        w.checkpoint,
+	}
 }
@@ -5,17 +5,18 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	ctypes "github.com/cometbft/cometbft/rpc/core/types"
 	"github.com/cometbft/cometbft/types"
 	"github.com/fatih/color"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/history"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/rpc"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/webhook"
 	"github.com/rs/zerolog/log"
-	"github.com/shopspring/decimal"
 )
 
 type BlockWebhook struct {
@@ -30,11 +31,28 @@ type BlockWatcher struct {
 	blockChan         chan *BlockInfo
 	validatorSet      atomic.Value // []*types.Validator
 	latestBlock       BlockInfo
+	latestRestored    bool          // latest block restored from the history (only its height is known)
+	restored          chan struct{} // closed once the history is restored
+	restoredHeight    int64         // height of the block following the history
+	restoredBlock     atomic.Bool   // restored block to fetch from a node
 	webhook           *webhook.Webhook
 	customWebhooks    []BlockWebhook
+	history           *history.Store // optional
+
+	mu           sync.RWMutex
+	recentBlocks []history.BlockRecord // latest evaluated heights, in height order
+	onBlock      []OnBlock
+
+	health *Health
 }
 
-func NewBlockWatcher(validators []TrackedValidator, metrics *metrics.Metrics, writer io.Writer, webhook *webhook.Webhook, customWebhooks []BlockWebhook) *BlockWatcher {
+// OnBlock is called with the outcome of the tracked validators for each height.
+type OnBlock func(record history.BlockRecord)
+
+// Number of recent blocks kept in memory
+const recentBlocksSize = 100
+
+func NewBlockWatcher(validators []TrackedValidator, metrics *metrics.Metrics, writer io.Writer, webhook *webhook.Webhook, customWebhooks []BlockWebhook, store *history.Store) *BlockWatcher {
 	return &BlockWatcher{
 		trackedValidators: validators,
 		metrics:           metrics,
@@ -42,16 +60,35 @@ func NewBlockWatcher(validators []TrackedValidator, metrics *metrics.Metrics, wr
 		blockChan:         make(chan *BlockInfo),
 		webhook:           webhook,
 		customWebhooks:    customWebhooks,
+		history:           store,
+		restored:          make(chan struct{}),
+		health:            NewHealth("block", metrics),
 	}
 }
 
+func (w *BlockWatcher) Health() *Health {
+	return w.health
+}
+
+// OnBlock registers a callback called each time a height is evaluated.
+func (w *BlockWatcher) OnBlock(callback OnBlock) {
+	w.onBlock = append(w.onBlock, callback)
+}
+
 func (w *BlockWatcher) Start(ctx context.Context) error {
+	if err := w.restoreHistory(); err != nil {
+		log.Error().Err(err).Msg("failed to restore signing history")
+	}
+	close(w.restored)
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
 		case block := <-w.blockChan:
 			w.handleBlockInfo(ctx, block)
+			w.health.Report(nil)
+			w.health.Processed(block.Height)
 		}
 	}
 }
@@ -61,13 +98,10 @@ func (w *BlockWatcher) OnNodeStart(ctx context.Context, node *rpc.Node) error {
 		return fmt.Errorf("failed to sync validator set: %w", err)
 	}
 
-	blockResp, err := node.Client.Block(ctx, nil)
-	if err != nil {
-		log.Warn().Err(err).
-			Str("node", node.Redacted()).
-			Msg("failed to get latest block")
-	} else {
-		w.handleNodeBlock(node, blockResp.Block)
+	// The latest blocks are synced by the node right after the start callbacks,
+	// from the block following the history if it was restored
+	if err := w.fetchRestoredBlock(ctx, node); err != nil {
+		log.Error().Err(err).Str("node", node.Redacted()).Msg("failed to fetch restored block")
 	}
 
 	// Ticker to sync validator set
@@ -90,16 +124,66 @@ func (w *BlockWatcher) OnNodeStart(ctx context.Context, node *rpc.Node) error {
 	return nil
 }
 
+// fetchRestoredBlock fetches the block following the restored history, so the
+// first record after a restart is complete.
+func (w *BlockWatcher) fetchRestoredBlock(ctx context.Context, node *rpc.Node) error {
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-w.restored:
+	}
+
+	// Only fetched once, by the first node to start
+	if !w.restoredBlock.CompareAndSwap(true, false) {
+		return nil
+	}
+
+	height := w.restoredHeight
+	result, err := node.Client.Block(ctx, &height)
+	if err != nil {
+		w.restoredBlock.Store(true)
+		return fmt.Errorf("failed to get block %d: %w", height, err)
+	}
+
+	select {
+	case w.blockChan <- NewBlockInfo(result.Block, w.computeValidatorStatus(result.Block)):
+	case <-ctx.Done():
+	}
+
+	return nil
+}
+
 func (w *BlockWatcher) OnNewBlock(ctx context.Context, node *rpc.Node, evt *ctypes.ResultEvent) error {
 	// Ignore blocks if node is catching up
 	if !node.IsSynced() {
 		return nil
 	}
 
+	return w.OnReplayedBlock(ctx, node, evt)
+}
+
+// OnReplayedBlock handles the blocks of a replay: they are not live, so they
+// are not ignored when the latest block of the node is too old.
+func (w *BlockWatcher) OnReplayedBlock(ctx context.Context, node *rpc.Node, evt *ctypes.ResultEvent) error {
 	blockEvent := evt.Data.(types.EventDataNewBlock)
 	block := blockEvent.Block
 
-	w.handleNodeBlock(node, block)
+	w.handleNodeBlock(ctx, block)
+
+	return nil
+}
+
+// OnNodeBlock exports the latest block height of each node.
+//
+// It is registered directly on the nodes, as the blocks of lagging nodes are
+// deduplicated before reaching OnNewBlock.
+func (w *BlockWatcher) OnNodeBlock(ctx context.Context, node *rpc.Node, evt *ctypes.ResultEvent) error {
+	if !node.IsSynced() {
+		return nil
+	}
+
+	block := evt.Data.(types.EventDataNewBlock).Block
+	w.metrics.NodeBlockHeight.WithLabelValues(node.ChainID(), node.Endpoint()).Set(float64(block.Height))
 
 	return nil
 }
@@ -115,18 +199,18 @@ func (w *BlockWatcher) OnValidatorSetUpdates(ctx context.Context, node *rpc.Node
 	return nil
 }
 
-func (w *BlockWatcher) handleNodeBlock(node *rpc.Node, block *types.Block) {
+func (w *BlockWatcher) handleNodeBlock(ctx context.Context, block *types.Block) {
 	validatorSet := w.getValidatorSet()
 
 	if len(validatorSet) != block.LastCommit.Size() {
 		log.Warn().Msgf("validator set size mismatch: %d vs %d", len(validatorSet), block.LastCommit.Size())
 	}
 
-	// Set node block height
-	w.metrics.NodeBlockHeight.WithLabelValues(node.ChainID(), node.Endpoint()).Set(float64(block.Height))
-
-	// Extract block info
-	w.blockChan <- NewBlockInfo(block, w.computeValidatorStatus(block))
+	// Extract block info (unless the watcher is stopped)
+	select {
+	case w.blockChan <- NewBlockInfo(block, w.computeValidatorStatus(block)):
+	case <-ctx.Done():
+	}
 }
 
 func (w *BlockWatcher) getValidatorSet() []*types.Validator {
@@ -163,14 +247,27 @@ func (w *BlockWatcher) syncValidatorSet(ctx context.Context, n *rpc.Node) error
 		Int("validators", len(validators)).
 		Msgf("validator set")
 
-	w.validatorSet.Store(validators)
+	w.SetValidatorSet(validators)
 
 	return nil
 }
 
+// SetValidatorSet replaces the validator set the signatures are checked
+// against (eg. with the set of a past height).
+func (w *BlockWatcher) SetValidatorSet(validators []*types.Validator) {
+	w.validatorSet.Store(validators)
+}
+
 func (w *BlockWatcher) handleBlockInfo(ctx context.Context, block *BlockInfo) {
 	chainId := block.ChainID
 
+	if w.latestRestored && w.latestBlock.Height == block.Height {
+		// The record of the restored block is built from the next block
+		w.latestBlock = *block
+		w.latestRestored = false
+		return
+	}
+
 	if w.latestBlock.Height >= block.Height {
 		// Skip already processed blocks
 		return
@@ -197,32 +294,38 @@ func (w *BlockWatcher) handleBlockInfo(ctx context.Context, block *BlockInfo) {
 	w.metrics.TrackedBlocks.WithLabelValues(chainId).Inc()
 	w.metrics.Transactions.WithLabelValues(chainId).Add(float64(block.Transactions))
 
+	// The previous block is unknown after skipped blocks or a restart (until
+	// the restored block is fetched)
+	previous := &w.latestBlock
+	if w.latestRestored || previous.Height != block.Height-1 {
+		previous = nil
+	}
+	record := NewBlockRecord(previous, block)
+
 	// Print block result & update metrics
 	validatorStatus := []string{}
 	for _, res := range block.ValidatorStatus {
 		icon := "⚪️"
-		if w.latestBlock.ProposerAddress == res.Address {
-			// Check if this is an empty block
-			if w.latestBlock.Transactions == 0 {
+		outcome, _ := record.Outcome(res.Address)
+		switch outcome.Outcome {
+		case history.OutcomeProposed, history.OutcomeEmpty:
+			icon = "👑"
+			if outcome.Outcome == history.OutcomeEmpty {
 				icon = "🟡"
 				w.metrics.EmptyBlocks.WithLabelValues(block.ChainID, res.Address, res.Label).Inc()
-			} else {
-				icon = "👑"
 			}
 			w.metrics.ProposedBlocks.WithLabelValues(block.ChainID, res.Address, res.Label).Inc()
 			w.metrics.ValidatedBlocks.WithLabelValues(block.ChainID, res.Address, res.Label).Inc()
 			w.metrics.ConsecutiveMissedBlocks.WithLabelValues(block.ChainID, res.Address, res.Label).Set(0)
-		} else if res.Signed {
+		case history.OutcomeSigned, history.OutcomeNil:
 			icon = "✅"
 			w.metrics.ValidatedBlocks.WithLabelValues(block.ChainID, res.Address, res.Label).Inc()
 			w.metrics.ConsecutiveMissedBlocks.WithLabelValues(block.ChainID, res.Address, res.Label).Set(0)
-		} else if res.Bonded {
+		case history.OutcomeAbsent:
 			icon = "❌"
 			w.metrics.MissedBlocks.WithLabelValues(block.ChainID, res.Address, res.Label).Inc()
 			w.metrics.ConsecutiveMissedBlocks.WithLabelValues(block.ChainID, res.Address, res.Label).Inc()
-
-			// Check if solo missed block
-			if block.SignedRatio().GreaterThan(decimal.NewFromFloat(0.66)) {
+			if outcome.Solo {
 				w.metrics.SoloMissedBlocks.WithLabelValues(block.ChainID, res.Address, res.Label).Inc()
 			}
 		}
@@ -239,21 +342,121 @@ func (w *BlockWatcher) handleBlockInfo(ctx context.Context, block *BlockInfo) {
 	// Handle webhooks
 	w.handleWebhooks(ctx, block)
 
+	// Keep & persist signing history (the first block has no previous block to evaluate)
+	if w.latestBlock.Height > 0 {
+		w.addRecentBlock(record)
+		for _, onBlock := range w.onBlock {
+			onBlock(record)
+		}
+
+		// Only complete records are persisted
+		if w.history != nil && previous != nil {
+			if err := w.history.Save(record); err != nil {
+				log.Error().Err(err).Msg("failed to save signing history")
+			}
+		}
+	}
+
 	// Save latest block
 	w.latestBlock = *block
+	w.latestRestored = false
+}
+
+func (w *BlockWatcher) addRecentBlock(record history.BlockRecord) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.recentBlocks = append(w.recentBlocks, record)
+	if len(w.recentBlocks) > recentBlocksSize {
+		w.recentBlocks = w.recentBlocks[len(w.recentBlocks)-recentBlocksSize:]
+	}
+}
+
+// RecentBlocks returns the outcomes of the tracked validators for the latest
+// heights (up to `limit`, most recent first).
+func (w *BlockWatcher) RecentBlocks(limit int) []history.BlockRecord {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if limit <= 0 || limit > len(w.recentBlocks) {
+		limit = len(w.recentBlocks)
+	}
+
+	blocks := make([]history.BlockRecord, 0, limit)
+	for i := len(w.recentBlocks) - 1; i >= len(w.recentBlocks)-limit; i-- {
+		blocks = append(blocks, w.recentBlocks[i])
+	}
+	return blocks
+}
+
+// restoreHistory resumes the counters from the signing history, so they are
+// not reset after a restart.
+func (w *BlockWatcher) restoreHistory() error {
+	if w.history == nil {
+		return nil
+	}
+
+	latest, err := w.history.Latest()
+	if err != nil || latest == nil {
+		return err
+	}
+	chainID := latest.ChainID
+
+	summary := history.NewSummary()
+	err = w.history.Range(0, 0, func(record history.BlockRecord) error {
+		summary.Add(record)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, val := range w.trackedValidators {
+		res := summary.Validator(val.Address, val.Name)
+		w.metrics.ValidatedBlocks.WithLabelValues(chainID, val.Address, val.Name).Add(float64(res.Validated))
+		w.metrics.MissedBlocks.WithLabelValues(chainID, val.Address, val.Name).Add(float64(res.Missed))
+		w.metrics.SoloMissedBlocks.WithLabelValues(chainID, val.Address, val.Name).Add(float64(res.SoloMissed))
+		w.metrics.ProposedBlocks.WithLabelValues(chainID, val.Address, val.Name).Add(float64(res.Proposed))
+		w.metrics.EmptyBlocks.WithLabelValues(chainID, val.Address, val.Name).Add(float64(res.Empty))
+		w.metrics.ConsecutiveMissedBlocks.WithLabelValues(chainID, val.Address, val.Name).Set(float64(res.ConsecutiveMissed))
+	}
+	w.metrics.TrackedBlocks.WithLabelValues(chainID).Add(float64(summary.Blocks))
+	w.metrics.Transactions.WithLabelValues(chainID).Add(float64(summary.Transactions))
+
+	// Resume after the latest recorded height (the record of height H is
+	// written when receiving block H+1)
+	w.latestBlock = BlockInfo{ChainID: chainID, Height: latest.Height + 1}
+	w.latestRestored = true
+	w.restoredHeight = latest.Height + 1
+	w.restoredBlock.Store(true)
+
+	log.Info().
+		Int64("height", latest.Height).
+		Int("blocks", summary.Blocks).
+		Msg("restored signing history")
+
+	return nil
 }
 
 func (w *BlockWatcher) computeValidatorStatus(block *types.Block) []ValidatorStatus {
+	return ComputeValidatorStatus(block, w.trackedValidators, w.getValidatorSet())
+}
+
+// ComputeValidatorStatus returns the signature status of the tracked
+// validators in the last commit of the block.
+func ComputeValidatorStatus(block *types.Block, trackedValidators []TrackedValidator, validatorSet []*types.Validator) []ValidatorStatus {
 	validatorStatus := []ValidatorStatus{}
 
-	for _, val := range w.trackedValidators {
-		bonded := w.isValidatorActive(val.Address)
+	for _, val := range trackedValidators {
+		bonded := isValidatorActive(validatorSet, val.Address)
 		signed := false
+		voteNil := false
 		rank := 0
 		for i, sig := range block.LastCommit.Signatures {
 			if val.Address == sig.ValidatorAddress.String() {
 				bonded = true
 				signed = (sig.BlockIDFlag != types.BlockIDFlagAbsent)
+				voteNil = (sig.BlockIDFlag == types.BlockIDFlagNil)
 				rank = i + 1
 			}
 			if signed {
@@ -265,6 +468,7 @@ func (w *BlockWatcher) computeValidatorStatus(block *types.Block) []ValidatorSta
 			Label:   val.Name,
 			Bonded:  bonded,
 			Signed:  signed,
+			Nil:     voteNil,
 			Rank:    rank,
 		})
 	}
@@ -272,8 +476,8 @@ func (w *BlockWatcher) computeValidatorStatus(block *types.Block) []ValidatorSta
 	return validatorStatus
 }
 
-func (w *BlockWatcher) isValidatorActive(address string) bool {
-	for _, val := range w.getValidatorSet() {
+func isValidatorActive(validatorSet []*types.Validator, address string) bool {
+	for _, val := range validatorSet {
 		if val.Address.String() == address {
 			return true
 		}
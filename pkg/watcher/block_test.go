@@ -3,11 +3,21 @@ package watcher
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/cometbft/cometbft/types"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/history"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/rpc"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/webhook"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"gotest.tools/assert"
@@ -31,8 +41,14 @@ func TestBlockWatcher(t *testing.T) {
 		&bytes.Buffer{},
 		webhook.New(url.URL{}),
 		[]BlockWebhook{},
+		nil,
 	)
 
+	records := []history.BlockRecord{}
+	blockWatcher.OnBlock(func(record history.BlockRecord) {
+		records = append(records, record)
+	})
+
 	t.Run("Handle BlockInfo", func(t *testing.T) {
 		blocks := []BlockInfo{
 			{
@@ -168,5 +184,211 @@ func TestBlockWatcher(t *testing.T) {
 		assert.Equal(t, float64(0), testutil.ToFloat64(blockWatcher.metrics.SoloMissedBlocks.WithLabelValues(chainID, kilnAddress, kilnName)))
 		assert.Equal(t, float64(0), testutil.ToFloat64(blockWatcher.metrics.ConsecutiveMissedBlocks.WithLabelValues(chainID, kilnAddress, kilnName)))
 		assert.Equal(t, float64(1), testutil.ToFloat64(blockWatcher.metrics.EmptyBlocks.WithLabelValues(chainID, kilnAddress, kilnName)))
+
+		recentBlocks := blockWatcher.RecentBlocks(2)
+		assert.Equal(t, 2, len(recentBlocks))
+		assert.Equal(t, int64(44), recentBlocks[0].Height)
+		assert.Equal(t, history.OutcomeEmpty, recentBlocks[0].Validators[0].Outcome)
+		assert.Equal(t, int64(43), recentBlocks[1].Height)
+		assert.Equal(t, history.OutcomeProposed, recentBlocks[1].Validators[0].Outcome)
+		assert.Equal(t, 5, len(blockWatcher.RecentBlocks(0)))
+
+		assert.Equal(t, 5, len(records))
+		assert.Equal(t, int64(44), records[len(records)-1].Height)
+	})
+}
+
+func TestBlockWatcherHistory(t *testing.T) {
+	var (
+		kilnAddress = "3DC4DD610817606AD4A8F9D762A068A81E8741E2"
+		kilnName    = "Kiln"
+		chainID     = "chain-42"
+		validators  = []TrackedValidator{{Address: kilnAddress, Name: kilnName}}
+	)
+
+	store, err := history.Open(t.TempDir(), chainID, 0)
+	assert.NilError(t, err)
+	defer store.Close()
+
+	t.Run("Save History", func(t *testing.T) {
+		blockWatcher := NewBlockWatcher(validators, metrics.New("cosmos_validator_watcher"), &bytes.Buffer{}, webhook.New(url.URL{}), []BlockWebhook{}, store)
+
+		// Kiln misses #40, signs #41 & #42 and proposes #43 & #44 (empty)
+		start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		signed := []bool{true, false, true, true, true, true}
+		proposers := []string{"", "", "", kilnAddress, kilnAddress, ""}
+		transactions := []int{3, 2, 1, 4, 0, 2}
+		for i := range signed {
+			blockWatcher.handleBlockInfo(context.Background(), &BlockInfo{
+				ChainID:          chainID,
+				Height:           int64(40 + i),
+				Time:             start.Add(time.Duration(i) * 6 * time.Second),
+				Transactions:     transactions[i],
+				TotalValidators:  2,
+				SignedValidators: 1,
+				ProposerAddress:  proposers[i],
+				ValidatorStatus: []ValidatorStatus{
+					{Address: kilnAddress, Label: kilnName, Bonded: true, Signed: signed[i], Rank: 1},
+				},
+			})
+		}
+
+		latest, err := store.Latest()
+		assert.NilError(t, err)
+		assert.Equal(t, int64(44), latest.Height)
+		assert.Equal(t, kilnAddress, latest.Proposer)
+		assert.Equal(t, 0, latest.Transactions)
+		assert.Assert(t, latest.Time.Equal(start.Add(24*time.Second)))
+		assert.DeepEqual(t, []history.ValidatorOutcome{{Address: kilnAddress, Name: kilnName, Outcome: history.OutcomeEmpty}}, latest.Validators)
+	})
+
+	t.Run("Restore History", func(t *testing.T) {
+		blockWatcher := NewBlockWatcher(validators, metrics.New("cosmos_validator_watcher"), &bytes.Buffer{}, webhook.New(url.URL{}), []BlockWebhook{}, store)
+		assert.NilError(t, blockWatcher.restoreHistory())
+
+		assert.Equal(t, int64(45), blockWatcher.latestBlock.Height)
+		assert.Equal(t, float64(5), testutil.ToFloat64(blockWatcher.metrics.TrackedBlocks.WithLabelValues(chainID)))
+		assert.Equal(t, float64(10), testutil.ToFloat64(blockWatcher.metrics.Transactions.WithLabelValues(chainID)))
+		assert.Equal(t, float64(4), testutil.ToFloat64(blockWatcher.metrics.ValidatedBlocks.WithLabelValues(chainID, kilnAddress, kilnName)))
+		assert.Equal(t, float64(1), testutil.ToFloat64(blockWatcher.metrics.MissedBlocks.WithLabelValues(chainID, kilnAddress, kilnName)))
+		assert.Equal(t, float64(2), testutil.ToFloat64(blockWatcher.metrics.ProposedBlocks.WithLabelValues(chainID, kilnAddress, kilnName)))
+		assert.Equal(t, float64(1), testutil.ToFloat64(blockWatcher.metrics.EmptyBlocks.WithLabelValues(chainID, kilnAddress, kilnName)))
+		assert.Equal(t, float64(0), testutil.ToFloat64(blockWatcher.metrics.ConsecutiveMissedBlocks.WithLabelValues(chainID, kilnAddress, kilnName)))
+
+		// Already recorded blocks are skipped
+		blockWatcher.handleBlockInfo(context.Background(), &BlockInfo{ChainID: chainID, Height: 44})
+		assert.Equal(t, float64(5), testutil.ToFloat64(blockWatcher.metrics.TrackedBlocks.WithLabelValues(chainID)))
+
+		// The restored block only completes the record of the next block
+		blockWatcher.handleBlockInfo(context.Background(), &BlockInfo{ChainID: chainID, Height: 45, ProposerAddress: kilnAddress, Transactions: 3})
+		assert.Equal(t, float64(5), testutil.ToFloat64(blockWatcher.metrics.TrackedBlocks.WithLabelValues(chainID)))
+
+		blockWatcher.handleBlockInfo(context.Background(), &BlockInfo{ChainID: chainID, Height: 46, ValidatorStatus: []ValidatorStatus{
+			{Address: kilnAddress, Label: kilnName, Bonded: true, Signed: true, Rank: 1},
+		}})
+		latest, err := store.Latest()
+		assert.NilError(t, err)
+		assert.Equal(t, int64(45), latest.Height)
+		assert.Equal(t, kilnAddress, latest.Proposer)
+		assert.DeepEqual(t, []history.ValidatorOutcome{{Address: kilnAddress, Name: kilnName, Outcome: history.OutcomeProposed}}, latest.Validators)
+
+		// Records after skipped blocks are not persisted either
+		blockWatcher.handleBlockInfo(context.Background(), &BlockInfo{ChainID: chainID, Height: 48})
+		blockWatcher.handleBlockInfo(context.Background(), &BlockInfo{ChainID: chainID, Height: 49})
+		latest, err = store.Latest()
+		assert.NilError(t, err)
+		assert.Equal(t, int64(48), latest.Height)
+	})
+}
+
+func TestBlockWatcherReplay(t *testing.T) {
+	var (
+		kilnAddress = "3DC4DD610817606AD4A8F9D762A068A81E8741E2"
+		kilnName    = "Kiln"
+		chainID     = "chain-42"
+	)
+
+	// The blocks are 5 years old: the node is not synced
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params struct {
+				Height string `json:"height"`
+			} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch req.Method {
+		case "status":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"node_info":{"network":"%s"},"sync_info":{"latest_block_height":"100","latest_block_time":"2020-01-01T00:00:00Z","earliest_block_height":"1","catching_up":false}}}`, req.ID, chainID)
+		case "block":
+			height, _ := strconv.ParseInt(req.Params.Height, 10, 64)
+			// Kiln misses the odd heights (signed in the next block)
+			flag := 2
+			if (height-1)%2 == 1 {
+				flag = 1
+			}
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"block_id":{"hash":"","parts":{"total":0,"hash":""}},"block":{"header":{"chain_id":"%s","height":"%d","time":"2020-01-01T00:00:00Z"},"data":{"txs":[]},"evidence":{"evidence":[]},"last_commit":{"height":"%d","round":0,"block_id":{"hash":"","parts":{"total":0,"hash":""}},"signatures":[{"block_id_flag":%d,"validator_address":"%s","timestamp":"2020-01-01T00:00:00Z","signature":null}]}}}}`, req.ID, chainID, height, height-1, flag, kilnAddress)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"Method not found"}}`, req.ID)
+		}
+	}))
+	defer server.Close()
+
+	replay := func(handler func(w *BlockWatcher) rpc.OnNodeEvent) (*BlockWatcher, []history.BlockRecord) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		blockWatcher := NewBlockWatcher([]TrackedValidator{{Address: kilnAddress, Name: kilnName}}, metrics.New("cosmos_validator_watcher"), &bytes.Buffer{}, nil, nil, nil)
+		records := []history.BlockRecord{}
+		blockWatcher.OnBlock(func(record history.BlockRecord) {
+			records = append(records, record)
+		})
+		stopped := make(chan struct{})
+		go func() {
+			blockWatcher.Start(ctx)
+			close(stopped)
+		}()
+
+		client, err := rpc.NewClient(server.URL, rpc.ClientOptions{})
+		assert.NilError(t, err)
+		node := rpc.NewNode(client)
+		node.OnEvent(rpc.EventNewBlock, handler(blockWatcher))
+
+		assert.NilError(t, node.Replay(ctx, 10, 15))
+		assert.Equal(t, false, node.IsSynced())
+
+		// Blocks are handled synchronously, wait for the last one
+		cancel()
+		<-stopped
+
+		return blockWatcher, records
+	}
+
+	t.Run("Replayed Blocks", func(t *testing.T) {
+		blockWatcher, records := replay(func(w *BlockWatcher) rpc.OnNodeEvent { return w.OnReplayedBlock })
+
+		assert.Equal(t, 5, len(records))
+		assert.Equal(t, int64(10), records[0].Height)
+		assert.Equal(t, int64(14), records[len(records)-1].Height)
+		assert.Equal(t, history.OutcomeSigned, records[0].Validators[0].Outcome)
+		assert.Equal(t, history.OutcomeAbsent, records[1].Validators[0].Outcome)
+		assert.Equal(t, float64(15), testutil.ToFloat64(blockWatcher.metrics.BlockHeight.WithLabelValues(chainID)))
+	})
+
+	t.Run("Live Blocks", func(t *testing.T) {
+		// Old blocks received live are ignored
+		_, records := replay(func(w *BlockWatcher) rpc.OnNodeEvent { return w.OnNewBlock })
+
+		assert.Equal(t, 0, len(records))
 	})
 }
+
+func TestBlockWatcherStopped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	blockWatcher := NewBlockWatcher(nil, metrics.New("cosmos_validator_watcher"), &bytes.Buffer{}, nil, nil, nil)
+	babylonWatcher := NewBabylonWatcher(nil, nil, nil, metrics.New("cosmos_validator_watcher"), &bytes.Buffer{})
+
+	block := &types.Block{Header: types.Header{Height: 10}, LastCommit: &types.Commit{Height: 9}}
+	evt := &ctypes.ResultEvent{Data: types.EventDataNewBlock{Block: block}}
+
+	// The replay is not blocked by watchers which are not running anymore
+	done := make(chan error, 2)
+	go func() {
+		done <- blockWatcher.OnReplayedBlock(ctx, nil, evt)
+		done <- babylonWatcher.OnReplayedBlock(ctx, nil, evt)
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			assert.NilError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("replayed block handlers blocked")
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package watcher
+
+import (
+	"sync"
+	"time"
+)
+
+// BlockTimes keeps a rolling window of recent block timestamps to estimate
+// the average block time of the chain.
+type BlockTimes struct {
+	mu      sync.RWMutex
+	size    int
+	samples []blockTimeSample
+}
+
+type blockTimeSample struct {
+	height int64
+	time   time.Time
+}
+
+func NewBlockTimes(size int) *BlockTimes {
+	if size < 2 {
+		size = 2
+	}
+	return &BlockTimes{
+		size:    size,
+		samples: make([]blockTimeSample, 0, size),
+	}
+}
+
+// Add records the time of a block. Blocks older than the latest known one are ignored.
+func (b *BlockTimes) Add(height int64, t time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n := len(b.samples); n > 0 && b.samples[n-1].height >= height {
+		return
+	}
+
+	b.samples = append(b.samples, blockTimeSample{height: height, time: t})
+	if len(b.samples) > b.size {
+		b.samples = b.samples[len(b.samples)-b.size:]
+	}
+}
+
+// Average returns the average block time over the window (0 if unknown).
+func (b *BlockTimes) Average() time.Duration {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.average()
+}
+
+func (b *BlockTimes) average() time.Duration {
+	if len(b.samples) < 2 {
+		return 0
+	}
+
+	first := b.samples[0]
+	last := b.samples[len(b.samples)-1]
+
+	return last.time.Sub(first.time) / time.Duration(last.height-first.height)
+}
+
+// Latest returns the height and time of the most recent block.
+func (b *BlockTimes) Latest() (int64, time.Time) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if len(b.samples) == 0 {
+		return 0, time.Time{}
+	}
+	last := b.samples[len(b.samples)-1]
+	return last.height, last.time
+}
+
+// EstimateTime returns the estimated time at which the given height will be reached.
+func (b *BlockTimes) EstimateTime(height int64) (time.Time, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	avg := b.average()
+	if avg <= 0 {
+		return time.Time{}, false
+	}
+
+	last := b.samples[len(b.samples)-1]
+	return last.time.Add(time.Duration(height-last.height) * avg), true
+}
@@ -1,13 +1,17 @@
 package watcher
 
 import (
+	"time"
+
 	"github.com/cometbft/cometbft/types"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/history"
 	"github.com/shopspring/decimal"
 )
 
 type BlockInfo struct {
 	ChainID          string
 	Height           int64
+	Time             time.Time
 	Transactions     int
 	TotalValidators  int
 	SignedValidators int
@@ -27,6 +31,7 @@ func NewBlockInfo(block *types.Block, validatorStatus []ValidatorStatus) *BlockI
 	return &BlockInfo{
 		ChainID:          block.Header.ChainID,
 		Height:           block.Header.Height,
+		Time:             block.Header.Time,
 		Transactions:     block.Txs.Len(),
 		TotalValidators:  len(block.LastCommit.Signatures),
 		SignedValidators: signedValidators,
@@ -49,5 +54,53 @@ type ValidatorStatus struct {
 	Label   string
 	Bonded  bool
 	Signed  bool
+	Nil     bool // voted nil (counted as signed)
 	Rank    int
 }
+
+// NewBlockRecord evaluates the outcome of each tracked validator for the
+// previous block (the signatures of a block are included in the next one).
+//
+// The proposer, transactions and time of the previous block are only known
+// when both blocks are contiguous (previous may be nil).
+func NewBlockRecord(previous *BlockInfo, block *BlockInfo) history.BlockRecord {
+	record := history.BlockRecord{
+		ChainID:     block.ChainID,
+		Height:      block.Height - 1,
+		SignedRatio: block.SignedRatio().InexactFloat64(),
+	}
+
+	contiguous := previous != nil && previous.Height == block.Height-1
+	if contiguous {
+		record.Time = previous.Time
+		record.Proposer = previous.ProposerAddress
+		record.Transactions = previous.Transactions
+	}
+
+	for _, res := range block.ValidatorStatus {
+		outcome := history.ValidatorOutcome{Address: res.Address, Name: res.Label}
+		if contiguous && previous.ProposerAddress == res.Address {
+			// Check if this is an empty block
+			if previous.Transactions == 0 {
+				outcome.Outcome = history.OutcomeEmpty
+			} else {
+				outcome.Outcome = history.OutcomeProposed
+			}
+		} else if res.Signed {
+			outcome.Outcome = history.OutcomeSigned
+			if res.Nil {
+				outcome.Outcome = history.OutcomeNil
+			}
+		} else if res.Bonded {
+			outcome.Outcome = history.OutcomeAbsent
+			// Check if solo missed block
+			outcome.Solo = block.SignedRatio().GreaterThan(decimal.NewFromFloat(0.66))
+		} else {
+			// Not in the active set
+			continue
+		}
+		record.Validators = append(record.Validators, outcome)
+	}
+
+	return record
+}
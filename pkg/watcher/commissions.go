@@ -3,6 +3,7 @@ package watcher
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/cosmos/cosmos-sdk/client"
@@ -17,16 +18,39 @@ type CommissionWatcher struct {
 	validators []TrackedValidator
 	metrics    *metrics.Metrics
 	pool       *rpc.Pool
+
+	mu          sync.RWMutex
+	commissions map[string]map[string]float64 // by validator address & denom
+
+	health *Health
 }
 
 func NewCommissionsWatcher(validators []TrackedValidator, metrics *metrics.Metrics, pool *rpc.Pool) *CommissionWatcher {
 	return &CommissionWatcher{
-		validators: validators,
-		metrics:    metrics,
-		pool:       pool,
+		validators:  validators,
+		metrics:     metrics,
+		pool:        pool,
+		commissions: make(map[string]map[string]float64),
+		health:      NewHealth("commissions", metrics),
 	}
 }
 
+func (w *CommissionWatcher) Health() *Health {
+	return w.health
+}
+
+// Commissions returns the latest known commission of a validator by denom.
+func (w *CommissionWatcher) Commissions(address string) map[string]float64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	commissions := make(map[string]float64, len(w.commissions[address]))
+	for denom, amount := range w.commissions[address] {
+		commissions[denom] = amount
+	}
+	return commissions
+}
+
 func (w *CommissionWatcher) Start(ctx context.Context) error {
 	ticker := time.NewTicker(1 * time.Minute)
 
@@ -34,9 +58,14 @@ func (w *CommissionWatcher) Start(ctx context.Context) error {
 		node := w.pool.GetSyncedNode()
 		if node == nil {
 			log.Warn().Msg("no node available to fetch validators commissions")
+			w.health.Report(errNoSyncedNode)
 		} else if err := w.fetchCommissions(ctx, node); err != nil {
 			log.Error().Err(err).Msg("failed to fetch validators commissions")
+			w.health.Report(err)
+		} else {
+			w.health.Report(nil)
 		}
+		w.health.Processed(0)
 
 		select {
 		case <-ctx.Done():
@@ -56,7 +85,7 @@ func (w *CommissionWatcher) fetchCommissions(ctx context.Context, node *rpc.Node
 }
 
 func (w *CommissionWatcher) fetchValidatorCommission(ctx context.Context, node *rpc.Node, validator TrackedValidator) error {
-	clientCtx := (client.Context{}).WithClient(node.Client)
+	clientCtx := (client.Context{}).WithClient(node.QueryClient())
 	queryClient := distribution.NewQueryClient(clientCtx)
 
 	commissionResq, err := queryClient.ValidatorCommission(ctx, &distribution.QueryValidatorCommissionRequest{
@@ -72,9 +101,15 @@ func (w *CommissionWatcher) fetchValidatorCommission(ctx context.Context, node *
 }
 
 func (w *CommissionWatcher) handleValidatorCommission(chainID string, validator TrackedValidator, coins types.DecCoins) {
+	commissions := make(map[string]float64, len(coins))
 	for _, commission := range coins {
 		w.metrics.Commission.
 			WithLabelValues(chainID, validator.Address, validator.Name, commission.Denom).
 			Set(commission.Amount.MustFloat64())
+		commissions[commission.Denom] = commission.Amount.MustFloat64()
 	}
+
+	w.mu.Lock()
+	w.commissions[validator.Address] = commissions
+	w.mu.Unlock()
 }
@@ -39,5 +39,6 @@ func TestCommissionsWatcher(t *testing.T) {
 
 		assert.Equal(t, float64(123), testutil.ToFloat64(watcher.metrics.Commission.WithLabelValues(chainID, kilnValidator.Address, kilnValidator.Name, "uatom")))
 		assert.Equal(t, float64(42), testutil.ToFloat64(watcher.metrics.Commission.WithLabelValues(chainID, kilnValidator.Address, kilnValidator.Name, "ibc/0025F8A87464A471E66B234C4F93AEC5B4DA3D42D7986451A059273426290DD5")))
+		assert.Equal(t, float64(123), watcher.Commissions(kilnValidator.Address)["uatom"])
 	})
 }
@@ -0,0 +1,332 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	upgrade "cosmossdk.io/x/upgrade/types"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	comettypes "github.com/cometbft/cometbft/types"
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/rpc"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/webhook"
+	"github.com/rs/zerolog/log"
+)
+
+// Age under which a block is considered to be produced by a live chain
+const recentBlockAge = 2 * time.Minute
+
+type HaltWatcher struct {
+	metrics *metrics.Metrics
+	pool    *rpc.Pool
+	webhook *webhook.Webhook
+	options HaltWatcherOptions
+
+	mu                sync.Mutex
+	chainID           string
+	latestBlockHeight int64         // highest block received across the pool
+	latestBlockTime   time.Time     // time of the highest block received
+	halted            bool          // true while the chain is considered halted
+	haltedAt          int64         // latest block height when the halt was detected
+	upgradePlan       *upgrade.Plan // upgrade plan scheduled on-chain
+	upgradeConfirmed  bool          // true once the upgrade plan has been applied
+
+	health *Health
+}
+
+type HaltWatcherOptions struct {
+	Threshold    time.Duration // time without new blocks before considering the chain halted
+	CheckUpgrade bool          // check upgrade plans to confirm they are applied
+}
+
+func NewHaltWatcher(metrics *metrics.Metrics, pool *rpc.Pool, webhook *webhook.Webhook, options HaltWatcherOptions) *HaltWatcher {
+	return &HaltWatcher{
+		metrics: metrics,
+		pool:    pool,
+		webhook: webhook,
+		options: options,
+		health:  NewHealth("halt", metrics),
+	}
+}
+
+func (w *HaltWatcher) Health() *Health {
+	return w.health
+}
+
+func (w *HaltWatcher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	upgradeTicker := time.NewTicker(1 * time.Minute)
+	defer upgradeTicker.Stop()
+
+	w.health.Report(w.syncUpgradePlan(ctx))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			w.checkHalt(ctx, time.Now())
+			w.checkUpgradeApplied(ctx)
+			w.health.Processed(0)
+		case <-upgradeTicker.C:
+			w.health.Report(w.syncUpgradePlan(ctx))
+		}
+	}
+}
+
+func (w *HaltWatcher) OnNewBlock(ctx context.Context, node *rpc.Node, evt *ctypes.ResultEvent) error {
+	blockEvent := evt.Data.(comettypes.EventDataNewBlock)
+	block := blockEvent.Block
+
+	// Ignore blocks if node is catching up (a recent block is handled anyway,
+	// the node status is still stale when the chain resumes after a halt)
+	if !node.IsSynced() && time.Since(block.Time) > recentBlockAge {
+		return nil
+	}
+
+	w.handleBlock(ctx, block.Header.ChainID, block.Height, block.Time)
+
+	return nil
+}
+
+func (w *HaltWatcher) handleBlock(ctx context.Context, chainID string, height int64, blockTime time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Skip already processed blocks
+	if w.latestBlockHeight >= height {
+		return
+	}
+
+	previousBlockTime := w.latestBlockTime
+
+	w.chainID = chainID
+	w.latestBlockHeight = height
+	w.latestBlockTime = blockTime
+
+	if !w.halted {
+		return
+	}
+
+	// Chain produces blocks again
+	downtime := blockTime.Sub(previousBlockTime)
+	haltedAt := w.haltedAt
+	w.halted = false
+	w.metrics.ChainHalted.WithLabelValues(chainID).Set(0)
+	w.metrics.ChainDowntime.WithLabelValues(chainID).Set(downtime.Seconds())
+
+	log.Info().
+		Int64("height", height).
+		Int64("halted-at", haltedAt).
+		Str("downtime", downtime.Round(time.Second).String()).
+		Msg("chain resumed")
+
+	if w.webhook != nil {
+		msg := haltMessage{
+			Type:            "chain_resumed",
+			ChainID:         chainID,
+			Block:           height,
+			HaltedAt:        haltedAt,
+			DowntimeSeconds: downtime.Seconds(),
+		}
+		if w.upgradePlan != nil {
+			msg.Upgrade = w.upgradePlan.Name
+			msg.UpgradeBlock = w.upgradePlan.Height
+		}
+		go w.triggerWebhook(ctx, msg)
+	}
+}
+
+func (w *HaltWatcher) checkHalt(ctx context.Context, now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// No block received yet
+	if w.latestBlockHeight == 0 {
+		return
+	}
+
+	sinceLatestBlock := now.Sub(w.latestBlockTime)
+	w.metrics.TimeSinceLatestBlock.WithLabelValues(w.chainID).Set(sinceLatestBlock.Seconds())
+
+	if w.halted {
+		return
+	}
+	if sinceLatestBlock < w.options.Threshold {
+		w.metrics.ChainHalted.WithLabelValues(w.chainID).Set(0)
+		return
+	}
+
+	w.halted = true
+	w.haltedAt = w.latestBlockHeight
+	w.metrics.ChainHalted.WithLabelValues(w.chainID).Set(1)
+
+	logger := log.Error().
+		Int64("height", w.latestBlockHeight).
+		Str("since", sinceLatestBlock.Round(time.Second).String())
+	if w.isUpgradeHalt() {
+		logger = logger.Str("upgrade", w.upgradePlan.Name)
+	}
+	logger.Msg("chain halted")
+
+	if w.webhook != nil {
+		msg := haltMessage{
+			Type:     "chain_halted",
+			ChainID:  w.chainID,
+			Block:    w.latestBlockHeight,
+			HaltedAt: w.latestBlockHeight,
+		}
+		if w.isUpgradeHalt() {
+			msg.Upgrade = w.upgradePlan.Name
+			msg.UpgradeBlock = w.upgradePlan.Height
+		}
+		go w.triggerWebhook(ctx, msg)
+	}
+}
+
+// isUpgradeHalt returns true when the chain stopped right before a known upgrade.
+func (w *HaltWatcher) isUpgradeHalt() bool {
+	return w.upgradePlan != nil && w.latestBlockHeight >= w.upgradePlan.Height-1
+}
+
+type haltMessage struct {
+	Type            string  `json:"type"`
+	ChainID         string  `json:"chain_id"`
+	Block           int64   `json:"block"`
+	HaltedAt        int64   `json:"halted_at"`
+	DowntimeSeconds float64 `json:"downtime_seconds,omitempty"`
+	Upgrade         string  `json:"upgrade,omitempty"`
+	UpgradeBlock    int64   `json:"upgrade_block,omitempty"`
+	UpgradeApplied  *bool   `json:"upgrade_applied,omitempty"`
+}
+
+func (w *HaltWatcher) triggerWebhook(ctx context.Context, msg haltMessage) {
+	if err := w.webhook.Send(ctx, msg); err != nil {
+		log.Error().Err(err).Msgf("failed to send %s webhook", msg.Type)
+	}
+}
+
+func (w *HaltWatcher) syncUpgradePlan(ctx context.Context) error {
+	if !w.options.CheckUpgrade {
+		return nil
+	}
+
+	// Keep the known plan while the chain is halted (nodes may be upgrading)
+	w.mu.Lock()
+	halted := w.halted
+	w.mu.Unlock()
+	if halted {
+		return nil
+	}
+
+	node := w.pool.GetSyncedNode()
+	if node == nil {
+		return errNoSyncedNode
+	}
+
+	// Not cached, the plan state is polled more often than the cache expires
+	clientCtx := (client.Context{}).WithClient(node.Client)
+	queryClient := upgrade.NewQueryClient(clientCtx)
+
+	resp, err := queryClient.CurrentPlan(ctx, &upgrade.QueryCurrentPlanRequest{})
+	if err != nil {
+		log.Error().Err(err).Str("node", node.Redacted()).Msg("failed to fetch current upgrade plan")
+		return err
+	}
+
+	w.handleCurrentPlan(resp.Plan)
+
+	return nil
+}
+
+func (w *HaltWatcher) handleCurrentPlan(plan *upgrade.Plan) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if plan == nil {
+		// The plan is removed once applied, or when cancelled before its height
+		if w.upgradePlan != nil && w.latestBlockHeight < w.upgradePlan.Height {
+			log.Info().Str("upgrade", w.upgradePlan.Name).Msg("upgrade plan cancelled")
+			w.upgradePlan = nil
+			w.upgradeConfirmed = false
+		}
+		return
+	}
+
+	if w.upgradePlan == nil || w.upgradePlan.Name != plan.Name || w.upgradePlan.Height != plan.Height {
+		w.upgradePlan = plan
+		w.upgradeConfirmed = false
+	}
+}
+
+// checkUpgradeApplied confirms the known upgrade plan has been applied once
+// the chain passed the upgrade height.
+func (w *HaltWatcher) checkUpgradeApplied(ctx context.Context) {
+	w.mu.Lock()
+	plan := w.upgradePlan
+	pending := plan != nil && !w.upgradeConfirmed && !w.halted && w.latestBlockHeight >= plan.Height
+	chainID := w.chainID
+	w.mu.Unlock()
+
+	if !pending {
+		return
+	}
+
+	node := w.pool.GetSyncedNode()
+	if node == nil {
+		return
+	}
+
+	// Not cached, the plan state is polled more often than the cache expires
+	clientCtx := (client.Context{}).WithClient(node.Client)
+	queryClient := upgrade.NewQueryClient(clientCtx)
+
+	resp, err := queryClient.AppliedPlan(ctx, &upgrade.QueryAppliedPlanRequest{Name: plan.Name})
+	if err != nil {
+		log.Error().Err(err).Str("node", node.Redacted()).Msg("failed to fetch applied upgrade plan")
+		return
+	}
+
+	w.handleAppliedPlan(ctx, chainID, *plan, resp.Height)
+}
+
+func (w *HaltWatcher) handleAppliedPlan(ctx context.Context, chainID string, plan upgrade.Plan, appliedHeight int64) {
+	applied := appliedHeight > 0
+
+	w.mu.Lock()
+	w.upgradeConfirmed = true
+	if applied {
+		w.upgradePlan = nil
+	}
+	w.mu.Unlock()
+
+	w.metrics.UpgradeApplied.WithLabelValues(chainID, plan.Name, fmt.Sprintf("%d", plan.Height)).Set(metrics.BoolToFloat64(applied))
+
+	if applied {
+		log.Info().
+			Str("upgrade", plan.Name).
+			Int64("height", appliedHeight).
+			Msg("upgrade applied")
+	} else {
+		log.Error().
+			Str("upgrade", plan.Name).
+			Int64("height", plan.Height).
+			Msg("upgrade height passed but upgrade plan has not been applied")
+	}
+
+	if w.webhook != nil {
+		go w.triggerWebhook(ctx, haltMessage{
+			Type:           "upgrade_applied",
+			ChainID:        chainID,
+			Block:          appliedHeight,
+			Upgrade:        plan.Name,
+			UpgradeBlock:   plan.Height,
+			UpgradeApplied: &applied,
+		})
+	}
+}
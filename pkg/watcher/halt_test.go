@@ -0,0 +1,121 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	upgrade "cosmossdk.io/x/upgrade/types"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	comettypes "github.com/cometbft/cometbft/types"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestHaltWatcher(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		chainID = "chain-42"
+		start   = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+
+	watcher := NewHaltWatcher(
+		metrics.New("cosmos_validator_watcher"),
+		nil,
+		nil,
+		HaltWatcherOptions{
+			Threshold: time.Minute,
+		},
+	)
+
+	t.Run("Handle Chain Halt", func(t *testing.T) {
+		watcher.upgradePlan = &upgrade.Plan{Name: "v42", Height: 101}
+
+		watcher.handleBlock(ctx, chainID, 99, start)
+		watcher.handleBlock(ctx, chainID, 100, start.Add(6*time.Second))
+		watcher.handleBlock(ctx, chainID, 98, start.Add(12*time.Second)) // ignored
+
+		watcher.checkHalt(ctx, start.Add(30*time.Second))
+		assert.Equal(t, false, watcher.halted)
+		assert.Equal(t, float64(0), testutil.ToFloat64(watcher.metrics.ChainHalted.WithLabelValues(chainID)))
+		assert.Equal(t, float64(24), testutil.ToFloat64(watcher.metrics.TimeSinceLatestBlock.WithLabelValues(chainID)))
+
+		watcher.checkHalt(ctx, start.Add(2*time.Minute))
+		assert.Equal(t, true, watcher.halted)
+		assert.Equal(t, int64(100), watcher.haltedAt)
+		assert.Equal(t, true, watcher.isUpgradeHalt())
+		assert.Equal(t, float64(1), testutil.ToFloat64(watcher.metrics.ChainHalted.WithLabelValues(chainID)))
+	})
+
+	t.Run("Handle Chain Resumed", func(t *testing.T) {
+		watcher.handleBlock(ctx, chainID, 101, start.Add(10*time.Minute+6*time.Second))
+
+		assert.Equal(t, false, watcher.halted)
+		assert.Equal(t, float64(0), testutil.ToFloat64(watcher.metrics.ChainHalted.WithLabelValues(chainID)))
+		assert.Equal(t, float64(600), testutil.ToFloat64(watcher.metrics.ChainDowntime.WithLabelValues(chainID)))
+	})
+
+	t.Run("Handle Applied Plan", func(t *testing.T) {
+		watcher.handleAppliedPlan(ctx, chainID, *watcher.upgradePlan, 101)
+
+		assert.Equal(t, true, watcher.upgradePlan == nil)
+		assert.Equal(t, float64(1), testutil.ToFloat64(watcher.metrics.UpgradeApplied.WithLabelValues(chainID, "v42", "101")))
+	})
+
+	t.Run("Handle Cancelled Plan", func(t *testing.T) {
+		watcher.handleCurrentPlan(&upgrade.Plan{Name: "v43", Height: 200})
+		assert.Equal(t, "v43", watcher.upgradePlan.Name)
+
+		// The plan disappears before its height
+		watcher.handleCurrentPlan(nil)
+		assert.Equal(t, true, watcher.upgradePlan == nil)
+
+		// The plan disappears once its height is reached (applied or not)
+		watcher.handleCurrentPlan(&upgrade.Plan{Name: "v44", Height: 101})
+		watcher.handleCurrentPlan(nil)
+		assert.Equal(t, "v44", watcher.upgradePlan.Name)
+	})
+}
+
+func TestHaltWatcherResume(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		chainID = "chain-42"
+		now     = time.Now()
+	)
+
+	watcher := NewHaltWatcher(
+		metrics.New("cosmos_validator_watcher"),
+		nil,
+		nil,
+		HaltWatcherOptions{
+			Threshold: time.Minute,
+		},
+	)
+
+	client, err := rpc.NewClient("http://localhost:26657", rpc.ClientOptions{})
+	assert.NilError(t, err)
+	node := rpc.NewNode(client) // status not synced yet
+	assert.Equal(t, false, node.IsSynced())
+
+	newBlock := func(height int64, blockTime time.Time) *ctypes.ResultEvent {
+		return &ctypes.ResultEvent{Data: comettypes.EventDataNewBlock{Block: &comettypes.Block{
+			Header: comettypes.Header{ChainID: chainID, Height: height, Time: blockTime},
+		}}}
+	}
+
+	watcher.handleBlock(ctx, chainID, 100, now.Add(-10*time.Minute))
+	watcher.checkHalt(ctx, now.Add(-5*time.Minute))
+	assert.Equal(t, true, watcher.halted)
+
+	// Old blocks of an unsynced node are ignored
+	assert.NilError(t, watcher.OnNewBlock(ctx, node, newBlock(101, now.Add(-9*time.Minute))))
+	assert.Equal(t, true, watcher.halted)
+
+	// The first block after the halt resumes the chain, despite the stale status
+	assert.NilError(t, watcher.OnNewBlock(ctx, node, newBlock(101, now)))
+	assert.Equal(t, false, watcher.halted)
+	assert.Equal(t, float64(600), testutil.ToFloat64(watcher.metrics.ChainDowntime.WithLabelValues(chainID)))
+}
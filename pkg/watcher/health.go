@@ -0,0 +1,117 @@
+package watcher
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+)
+
+// Consecutive failures after which a watcher is reported unhealthy
+const healthFailureThreshold = 3
+
+var errNoSyncedNode = errors.New("no synced node available")
+
+// Health tracks the result of the runs of a watcher loop.
+type Health struct {
+	name    string
+	metrics *metrics.Metrics // optional
+
+	mu                  sync.RWMutex
+	lastSuccess         time.Time
+	lastError           error
+	lastErrorTime       time.Time
+	consecutiveFailures int
+	lastProcessed       time.Time
+	lastHeight          int64
+}
+
+// HealthStatus is a snapshot of the health of a watcher.
+type HealthStatus struct {
+	Name                string     `json:"name"`
+	Healthy             bool       `json:"healthy"`
+	LastSuccess         *time.Time `json:"last_success"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorTime       *time.Time `json:"last_error_time,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastProcessed       *time.Time `json:"last_processed"`
+	LastHeight          int64      `json:"last_height,omitempty"`
+}
+
+func NewHealth(name string, metrics *metrics.Metrics) *Health {
+	return &Health{name: name, metrics: metrics}
+}
+
+func (h *Health) Name() string {
+	return h.name
+}
+
+// Report records the result of a run (nil for a successful run).
+func (h *Health) Report(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err == nil {
+		h.lastSuccess = time.Now()
+		h.consecutiveFailures = 0
+		return
+	}
+
+	h.lastError = err
+	h.lastErrorTime = time.Now()
+	h.consecutiveFailures++
+}
+
+// Processed records an iteration of the watcher loop, with the block height
+// processed if any (a loop stuck or deadlocked stops updating it).
+func (h *Health) Processed(height int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastProcessed = time.Now()
+	if height > h.lastHeight {
+		h.lastHeight = height
+	}
+
+	if h.metrics != nil {
+		h.metrics.WatcherLastProcessedTime.WithLabelValues(h.name).Set(float64(h.lastProcessed.Unix()))
+		if h.lastHeight > 0 {
+			h.metrics.WatcherLastProcessedHeight.WithLabelValues(h.name).Set(float64(h.lastHeight))
+		}
+	}
+}
+
+// IsHealthy reports if the latest runs did not fail repeatedly.
+func (h *Health) IsHealthy() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.consecutiveFailures < healthFailureThreshold
+}
+
+func (h *Health) Status() HealthStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	status := HealthStatus{
+		Name:                h.name,
+		Healthy:             h.consecutiveFailures < healthFailureThreshold,
+		ConsecutiveFailures: h.consecutiveFailures,
+		LastHeight:          h.lastHeight,
+	}
+	if !h.lastProcessed.IsZero() {
+		lastProcessed := h.lastProcessed
+		status.LastProcessed = &lastProcessed
+	}
+	if !h.lastSuccess.IsZero() {
+		lastSuccess := h.lastSuccess
+		status.LastSuccess = &lastSuccess
+	}
+	if h.lastError != nil {
+		lastErrorTime := h.lastErrorTime
+		status.LastError = h.lastError.Error()
+		status.LastErrorTime = &lastErrorTime
+	}
+	return status
+}
@@ -0,0 +1,61 @@
+package watcher
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestHealth(t *testing.T) {
+	health := NewHealth("votes", metrics.New("cosmos_validator_watcher"))
+
+	t.Run("Initial State", func(t *testing.T) {
+		status := health.Status()
+		assert.Equal(t, "votes", status.Name)
+		assert.Equal(t, true, status.Healthy)
+		assert.Assert(t, status.LastSuccess == nil)
+		assert.Assert(t, status.LastErrorTime == nil)
+	})
+
+	t.Run("Report Failures", func(t *testing.T) {
+		health.Report(nil)
+		for i := 0; i < healthFailureThreshold-1; i++ {
+			health.Report(errNoSyncedNode)
+		}
+		assert.Equal(t, true, health.IsHealthy())
+
+		health.Report(errors.New("rpc error: code = Unknown"))
+		assert.Equal(t, false, health.IsHealthy())
+
+		status := health.Status()
+		assert.Equal(t, false, status.Healthy)
+		assert.Equal(t, healthFailureThreshold, status.ConsecutiveFailures)
+		assert.Equal(t, "rpc error: code = Unknown", status.LastError)
+		assert.Assert(t, status.LastSuccess != nil)
+		assert.Assert(t, !status.LastErrorTime.Before(*status.LastSuccess))
+	})
+
+	t.Run("Recover", func(t *testing.T) {
+		health.Report(nil)
+
+		status := health.Status()
+		assert.Equal(t, true, status.Healthy)
+		assert.Equal(t, 0, status.ConsecutiveFailures)
+		assert.Equal(t, "rpc error: code = Unknown", status.LastError)
+	})
+	t.Run("Processed", func(t *testing.T) {
+		health.Processed(42)
+		health.Processed(0)
+
+		status := health.Status()
+		assert.Equal(t, int64(42), status.LastHeight)
+		assert.Assert(t, status.LastProcessed != nil)
+
+		assert.Equal(t, float64(42), testutil.ToFloat64(health.metrics.WatcherLastProcessedHeight.WithLabelValues("votes")))
+		assert.Assert(t, testutil.ToFloat64(health.metrics.WatcherLastProcessedTime.WithLabelValues("votes")) >= float64(time.Now().Add(-time.Minute).Unix()))
+	})
+}
@@ -3,6 +3,7 @@ package watcher
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/cosmos/cosmos-sdk/client"
@@ -16,20 +17,28 @@ type SlashingWatcher struct {
 	metrics *metrics.Metrics
 	pool    *rpc.Pool
 
+	mu                      sync.RWMutex
 	signedBlocksWindow      int64
 	minSignedPerWindow      float64
 	downtimeJailDuration    float64
 	slashFractionDoubleSign float64
 	slashFractionDowntime   float64
+
+	health *Health
 }
 
 func NewSlashingWatcher(metrics *metrics.Metrics, pool *rpc.Pool) *SlashingWatcher {
 	return &SlashingWatcher{
 		metrics: metrics,
 		pool:    pool,
+		health:  NewHealth("slashing", metrics),
 	}
 }
 
+func (w *SlashingWatcher) Health() *Health {
+	return w.health
+}
+
 func (w *SlashingWatcher) Start(ctx context.Context) error {
 	// update metrics every 30 minutes
 	ticker := time.NewTicker(30 * time.Minute)
@@ -38,11 +47,16 @@ func (w *SlashingWatcher) Start(ctx context.Context) error {
 		node := w.pool.GetSyncedNode()
 		if node == nil {
 			log.Warn().Msg("no node available to fetch slashing parameters")
+			w.health.Report(errNoSyncedNode)
 		} else if err := w.fetchSlashingParameters(ctx, node); err != nil {
 			log.Error().Err(err).
 				Str("node", node.Redacted()).
 				Msg("failed to fetch slashing parameters")
+			w.health.Report(err)
+		} else {
+			w.health.Report(nil)
 		}
+		w.health.Processed(0)
 
 		select {
 		case <-ctx.Done():
@@ -53,7 +67,7 @@ func (w *SlashingWatcher) Start(ctx context.Context) error {
 }
 
 func (w *SlashingWatcher) fetchSlashingParameters(ctx context.Context, node *rpc.Node) error {
-	clientCtx := (client.Context{}).WithClient(node.Client)
+	clientCtx := (client.Context{}).WithClient(node.QueryClient())
 	queryClient := slashing.NewQueryClient(clientCtx)
 	sigininParams, err := queryClient.Params(ctx, &slashing.QueryParamsRequest{})
 	if err != nil {
@@ -76,11 +90,13 @@ func (w *SlashingWatcher) handleSlashingParams(chainID string, params slashing.P
 		Str("slashFractionDowntime", fmt.Sprintf("%.2f", params.SlashFractionDowntime.MustFloat64())).
 		Msgf("updating slashing metrics")
 
+	w.mu.Lock()
 	w.signedBlocksWindow = params.SignedBlocksWindow
 	w.minSignedPerWindow, _ = params.MinSignedPerWindow.Float64()
 	w.downtimeJailDuration = params.DowntimeJailDuration.Seconds()
 	w.slashFractionDoubleSign, _ = params.SlashFractionDoubleSign.Float64()
 	w.slashFractionDowntime, _ = params.SlashFractionDowntime.Float64()
+	w.mu.Unlock()
 
 	w.metrics.SignedBlocksWindow.WithLabelValues(chainID).Set(float64(w.signedBlocksWindow))
 	w.metrics.MinSignedBlocksPerWindow.WithLabelValues(chainID).Set(w.minSignedPerWindow)
@@ -88,3 +104,17 @@ func (w *SlashingWatcher) handleSlashingParams(chainID string, params slashing.P
 	w.metrics.SlashFractionDoubleSign.WithLabelValues(chainID).Set(w.slashFractionDoubleSign)
 	w.metrics.SlashFractionDowntime.WithLabelValues(chainID).Set(w.slashFractionDowntime)
 }
+
+// Params returns the latest known slashing parameters.
+func (w *SlashingWatcher) Params() SlashingState {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return SlashingState{
+		SignedBlocksWindow:      w.signedBlocksWindow,
+		MinSignedPerWindow:      w.minSignedPerWindow,
+		DowntimeJailDuration:    w.downtimeJailDuration,
+		SlashFractionDoubleSign: w.slashFractionDoubleSign,
+		SlashFractionDowntime:   w.slashFractionDowntime,
+	}
+}
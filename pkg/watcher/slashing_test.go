@@ -40,6 +40,7 @@ func TestSlashingWatcher(t *testing.T) {
 		assert.Equal(t, float64(10), testutil.ToFloat64(watcher.metrics.DowntimeJailDuration.WithLabelValues(chainID)))
 		assert.Equal(t, float64(0.01), testutil.ToFloat64(watcher.metrics.SlashFractionDoubleSign.WithLabelValues(chainID)))
 		assert.Equal(t, float64(0.001), testutil.ToFloat64(watcher.metrics.SlashFractionDowntime.WithLabelValues(chainID)))
+		assert.Equal(t, int64(1000), watcher.Params().SignedBlocksWindow)
 	})
 
 }
@@ -0,0 +1,59 @@
+package watcher
+
+import "time"
+
+// Snapshots of the watchers state, exposed by the HTTP API.
+
+// ValidatorState is the latest known state of a tracked validator.
+type ValidatorState struct {
+	Address            string             `json:"address"`
+	Name               string             `json:"name"`
+	Moniker            string             `json:"moniker,omitempty"`
+	OperatorAddress    string             `json:"operator_address,omitempty"`
+	ConsensusAddress   string             `json:"consensus_address,omitempty"`
+	Rank               int                `json:"rank,omitempty"`
+	Tokens             float64            `json:"tokens"`
+	Bonded             bool               `json:"bonded"`
+	Jailed             bool               `json:"jailed"`
+	MissedBlocksWindow int64              `json:"missed_blocks_window"`
+	Commission         map[string]float64 `json:"commission,omitempty"` // by denom
+}
+
+// ProposalState is a proposal in voting period with the votes of the tracked validators.
+type ProposalState struct {
+	ID            uint64         `json:"id"`
+	VotingEndTime time.Time      `json:"voting_end_time"`
+	Votes         []ProposalVote `json:"votes"`
+}
+
+type ProposalVote struct {
+	Address  string `json:"address"`
+	Name     string `json:"name"`
+	Voted    bool   `json:"voted"`
+	Option   string `json:"option,omitempty"`
+	Expected string `json:"expected,omitempty"` // from the expected vote policy
+}
+
+// UpgradeState is the next upgrade plan (on-chain or from a proposal).
+type UpgradeState struct {
+	Name   string     `json:"name"`
+	Height int64      `json:"height"`
+	Info   string     `json:"info,omitempty"`
+	ETA    *time.Time `json:"eta,omitempty"`
+}
+
+// SlashingState holds the slashing parameters of the chain.
+type SlashingState struct {
+	SignedBlocksWindow      int64   `json:"signed_blocks_window"`
+	MinSignedPerWindow      float64 `json:"min_signed_per_window"`
+	DowntimeJailDuration    float64 `json:"downtime_jail_duration"` // seconds
+	SlashFractionDoubleSign float64 `json:"slash_fraction_double_sign"`
+	SlashFractionDowntime   float64 `json:"slash_fraction_downtime"`
+}
+
+// BabylonState holds the latest finality & checkpoint votes (nil until received).
+type BabylonState struct {
+	FinalityProviders []BabylonFinalityProvider `json:"finality_providers"`
+	Finality          *BabylonFinality          `json:"finality"`
+	Checkpoint        *BabylonCheckpoint        `json:"checkpoint"`
+}
@@ -3,39 +3,73 @@ package watcher
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
+	upgrade "cosmossdk.io/x/upgrade/types"
 	ctypes "github.com/cometbft/cometbft/rpc/core/types"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/rpc"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/webhook"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
 )
 
 type StatusWatcher struct {
 	metrics    *metrics.Metrics
+	webhook    *webhook.Webhook
 	chainID    string
 	statusChan chan *ctypes.ResultStatus
+
+	mu                 sync.Mutex
+	upgradePlan        *upgrade.Plan     // latest known upgrade plan
+	latestBlockHeight  int64             // highest block height reported by nodes
+	preUpgradeVersions map[string]string // app version of each node before the upgrade height
+	laggingNodes       map[string]bool   // nodes currently lagging behind the pool
+
+	onNetInfo []OnNodeNetInfo
+
+	health *Health
 }
 
-func NewStatusWatcher(chainID string, metrics *metrics.Metrics) *StatusWatcher {
+type OnNodeNetInfo func(ctx context.Context, n *rpc.Node, status *ctypes.ResultStatus, netInfo *ctypes.ResultNetInfo) error
+
+func NewStatusWatcher(chainID string, metrics *metrics.Metrics, webhook *webhook.Webhook) *StatusWatcher {
 	return &StatusWatcher{
-		metrics:    metrics,
-		chainID:    chainID,
-		statusChan: make(chan *ctypes.ResultStatus),
+		metrics:            metrics,
+		webhook:            webhook,
+		chainID:            chainID,
+		statusChan:         make(chan *ctypes.ResultStatus),
+		preUpgradeVersions: make(map[string]string),
+		laggingNodes:       make(map[string]bool),
+		health:             NewHealth("status", metrics),
 	}
 }
 
+func (w *StatusWatcher) Health() *Health {
+	return w.health
+}
+
+// OnNodeNetInfo registers a callback called each time the peers of a node are fetched.
+func (w *StatusWatcher) OnNodeNetInfo(callback OnNodeNetInfo) {
+	w.onNetInfo = append(w.onNetInfo, callback)
+}
+
 func (w *StatusWatcher) Start(ctx context.Context) error {
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
 		case status := <-w.statusChan:
-			if status == nil {
-				continue
-			} else if w.chainID == "" {
+			// Nodes on another network are quarantined by the pool
+			if status != nil && w.chainID == "" {
 				w.chainID = status.NodeInfo.Network
-			} else if w.chainID != status.NodeInfo.Network {
-				return fmt.Errorf("chain ID mismatch: %s != %s", w.chainID, status.NodeInfo.Network)
+			}
+			w.health.Report(nil)
+			if status != nil {
+				w.health.Processed(status.SyncInfo.LatestBlockHeight)
 			}
 		}
 	}
@@ -62,7 +96,263 @@ func (w *StatusWatcher) OnNodeStatus(ctx context.Context, n *rpc.Node, status *c
 		metrics.BoolToFloat64(synced),
 	)
 
+	w.handleNodeQuarantine(chainID, n.Endpoint(), n.QuarantineReasons())
+	w.handleNodeLag(chainID, n.Endpoint(), n.Lag(), n.IsLagging())
+
+	if status != nil {
+		// ABCI info is fetched by the node along with its status
+		if abciInfo := n.ABCIInfo(); abciInfo != nil {
+			w.handleNodeVersion(chainID, n.Endpoint(), blockHeight, NodeVersion{
+				Version:     status.NodeInfo.Version,
+				AppName:     abciInfo.Response.Data,
+				AppVersion:  abciInfo.Response.Version,
+				AppProtocol: abciInfo.Response.AppVersion,
+			})
+		}
+
+		w.handleClockDrift(chainID, n.Endpoint(), status.SyncInfo.LatestBlockTime, time.Now())
+		w.syncNodeNetwork(ctx, n, status)
+	}
+
 	w.statusChan <- status
 
 	return nil
 }
+
+func (w *StatusWatcher) handleNodeQuarantine(chainID string, endpoint string, reasons []string) {
+	for _, reason := range rpc.QuarantineReasons {
+		w.metrics.NodeQuarantined.WithLabelValues(chainID, endpoint, reason).Set(
+			metrics.BoolToFloat64(lo.Contains(reasons, reason)),
+		)
+	}
+}
+
+func (w *StatusWatcher) handleNodeLag(chainID string, endpoint string, lag int64, lagging bool) {
+	// Only log when the node starts or stops lagging
+	w.mu.Lock()
+	wasLagging := w.laggingNodes[endpoint]
+	if lagging {
+		w.laggingNodes[endpoint] = true
+	} else {
+		delete(w.laggingNodes, endpoint)
+	}
+	w.mu.Unlock()
+
+	if lagging && !wasLagging {
+		log.Warn().
+			Str("node", endpoint).
+			Int64("lag", lag).
+			Msg("node is lagging behind the other nodes (excluded from the pool)")
+	} else if !lagging && wasLagging {
+		log.Info().
+			Str("node", endpoint).
+			Int64("lag", lag).
+			Msg("node caught up with the other nodes")
+	}
+
+	w.metrics.NodeLag.WithLabelValues(chainID, endpoint).Set(float64(lag))
+}
+
+// OnNodeDivergence reports nodes diverging from the other nodes of the pool
+// (forked, corrupted state or stale).
+func (w *StatusWatcher) OnNodeDivergence(ctx context.Context, n *rpc.Node, kind string, divergence *rpc.Divergence) error {
+	w.handleNodeDivergence(ctx, n.ChainID(), n.Endpoint(), kind, divergence)
+	return nil
+}
+
+func (w *StatusWatcher) handleNodeDivergence(ctx context.Context, chainID string, endpoint string, kind string, divergence *rpc.Divergence) {
+	if divergence == nil {
+		log.Info().Str("node", endpoint).Str("kind", kind).Msg("node no longer diverges from the pool")
+		w.metrics.NodeDivergence.WithLabelValues(chainID, endpoint, kind).Set(0)
+		return
+	}
+
+	log.Error().
+		Str("node", endpoint).
+		Str("kind", kind).
+		Int64("height", divergence.Height).
+		Str("expected", divergence.Expected).
+		Str("actual", divergence.Actual).
+		Msg("node diverges from the pool")
+
+	w.metrics.NodeDivergence.WithLabelValues(chainID, endpoint, kind).Set(float64(divergence.Height))
+
+	if w.webhook == nil {
+		return
+	}
+
+	msg := struct {
+		Type     string `json:"type"`
+		ChainID  string `json:"chain_id"`
+		Node     string `json:"node"`
+		Kind     string `json:"kind"`
+		Height   int64  `json:"height"`
+		Expected string `json:"expected"`
+		Actual   string `json:"actual"`
+	}{
+		Type:     "node_divergence",
+		ChainID:  chainID,
+		Node:     endpoint,
+		Kind:     kind,
+		Height:   divergence.Height,
+		Expected: divergence.Expected,
+		Actual:   divergence.Actual,
+	}
+
+	go func() {
+		if err := w.webhook.Send(ctx, msg); err != nil {
+			log.Error().Err(err).Msg("failed to send node_divergence webhook")
+		}
+	}()
+}
+
+// syncNodeNetwork fetches the peers & mempool of the node.
+//
+// Public nodes often restrict these endpoints, errors are only reported in debug.
+func (w *StatusWatcher) syncNodeNetwork(ctx context.Context, n *rpc.Node, status *ctypes.ResultStatus) {
+	chainID := status.NodeInfo.Network
+
+	netInfo, err := n.Client.NetInfo(ctx)
+	if err != nil {
+		log.Debug().Err(err).Str("node", n.Redacted()).Msg("failed to get net info")
+	} else {
+		w.handleNetInfo(chainID, n.Endpoint(), netInfo)
+
+		for _, onNetInfo := range w.onNetInfo {
+			if err := onNetInfo(ctx, n, status, netInfo); err != nil {
+				log.Error().Err(err).Str("node", n.Redacted()).Msg("failed to call net info callback")
+			}
+		}
+	}
+
+	mempool, err := n.Client.NumUnconfirmedTxs(ctx)
+	if err != nil {
+		log.Debug().Err(err).Str("node", n.Redacted()).Msg("failed to get unconfirmed txs")
+	} else {
+		w.handleMempool(chainID, n.Endpoint(), mempool)
+	}
+}
+
+func (w *StatusWatcher) handleNetInfo(chainID string, endpoint string, netInfo *ctypes.ResultNetInfo) {
+	inbound, outbound := 0, 0
+	for _, peer := range netInfo.Peers {
+		if peer.IsOutbound {
+			outbound++
+		} else {
+			inbound++
+		}
+	}
+
+	w.metrics.NodePeers.WithLabelValues(chainID, endpoint, "inbound").Set(float64(inbound))
+	w.metrics.NodePeers.WithLabelValues(chainID, endpoint, "outbound").Set(float64(outbound))
+}
+
+func (w *StatusWatcher) handleMempool(chainID string, endpoint string, mempool *ctypes.ResultUnconfirmedTxs) {
+	w.metrics.NodeMempoolTxs.WithLabelValues(chainID, endpoint).Set(float64(mempool.Total))
+	w.metrics.NodeMempoolBytes.WithLabelValues(chainID, endpoint).Set(float64(mempool.TotalBytes))
+}
+
+// handleClockDrift exports the difference between the local clock and the
+// time of the latest block known by the node.
+func (w *StatusWatcher) handleClockDrift(chainID string, endpoint string, latestBlockTime time.Time, now time.Time) {
+	w.metrics.NodeClockDrift.WithLabelValues(chainID, endpoint).Set(now.Sub(latestBlockTime).Seconds())
+}
+
+// OnUpgradePlan keeps track of the latest known upgrade plan.
+//
+// The plan is kept once it disappears (ie. once applied) to keep checking the
+// nodes after the upgrade height.
+func (w *StatusWatcher) OnUpgradePlan(chainID string, plan *upgrade.Plan) {
+	if plan == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.upgradePlan != nil && w.upgradePlan.Name == plan.Name && w.upgradePlan.Height == plan.Height {
+		return
+	}
+
+	w.upgradePlan = plan
+	w.preUpgradeVersions = make(map[string]string)
+	w.metrics.NodeUpgradeOutdated.Reset()
+	w.metrics.NodeUpgradeEarly.Reset()
+}
+
+type NodeVersion struct {
+	Version     string // CometBFT version
+	AppName     string
+	AppVersion  string
+	AppProtocol uint64
+}
+
+func (w *StatusWatcher) handleNodeVersion(chainID string, endpoint string, blockHeight int64, version NodeVersion) {
+	w.metrics.NodeInfo.DeletePartialMatch(prometheus.Labels{"node": endpoint})
+	w.metrics.NodeInfo.
+		WithLabelValues(chainID, endpoint, version.Version, version.AppName, version.AppVersion, fmt.Sprintf("%d", version.AppProtocol)).
+		Set(1)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if blockHeight > w.latestBlockHeight {
+		w.latestBlockHeight = blockHeight
+	}
+
+	plan := w.upgradePlan
+	if plan == nil {
+		return
+	}
+
+	var (
+		labels     = []string{chainID, endpoint, plan.Name}
+		newVersion = versionMatchesPlan(version.AppVersion, plan.Name)
+		outdated   = false
+		early      = false
+	)
+
+	if w.latestBlockHeight < plan.Height {
+		// Before the upgrade: nodes should not run the new version yet
+		early = newVersion
+		if !newVersion {
+			w.preUpgradeVersions[endpoint] = version.AppVersion
+		}
+	} else if preVersion, ok := w.preUpgradeVersions[endpoint]; ok {
+		// After the upgrade: nodes should not run the version they were running before
+		outdated = !newVersion && version.AppVersion == preVersion
+	}
+
+	if early {
+		log.Warn().
+			Str("node", endpoint).
+			Str("version", version.AppVersion).
+			Str("upgrade", plan.Name).
+			Msg("node reports the upgrade version before the upgrade height")
+	}
+	if outdated {
+		log.Warn().
+			Str("node", endpoint).
+			Str("version", version.AppVersion).
+			Str("upgrade", plan.Name).
+			Msg("node still runs the old binary after the upgrade height")
+	}
+
+	w.metrics.NodeUpgradeEarly.WithLabelValues(labels...).Set(metrics.BoolToFloat64(early))
+	w.metrics.NodeUpgradeOutdated.WithLabelValues(labels...).Set(metrics.BoolToFloat64(outdated))
+}
+
+// versionMatchesPlan checks if an app version corresponds to an upgrade plan name
+// (eg. version 15.0.1 for plan v15, or v15.0.0 for plan v15.0.0).
+func versionMatchesPlan(version string, planName string) bool {
+	version = strings.TrimPrefix(strings.ToLower(version), "v")
+	planName = strings.TrimPrefix(strings.ToLower(planName), "v")
+
+	if version == "" || planName == "" {
+		return false
+	}
+
+	return version == planName ||
+		strings.HasPrefix(version, planName+".") ||
+		strings.HasPrefix(version, planName+"-")
+}
@@ -0,0 +1,105 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	upgrade "cosmossdk.io/x/upgrade/types"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestStatusWatcher(t *testing.T) {
+	var (
+		chainID = "chain-42"
+		nodeA   = "http://node-a:26657"
+		nodeB   = "http://node-b:26657"
+	)
+
+	watcher := NewStatusWatcher(chainID, metrics.New("cosmos_validator_watcher"), nil)
+
+	t.Run("Handle Node Version", func(t *testing.T) {
+		watcher.handleNodeVersion(chainID, nodeA, 90, NodeVersion{Version: "0.38.15", AppName: "gaia", AppVersion: "v14.2.0"})
+		watcher.handleNodeVersion(chainID, nodeA, 90, NodeVersion{Version: "0.38.15", AppName: "gaia", AppVersion: "v14.2.1"})
+
+		assert.Equal(t, 1, testutil.CollectAndCount(watcher.metrics.NodeInfo))
+		assert.Equal(t, float64(1), testutil.ToFloat64(watcher.metrics.NodeInfo.WithLabelValues(chainID, nodeA, "0.38.15", "gaia", "v14.2.1", "0")))
+	})
+
+	t.Run("Handle Upgrade Readiness", func(t *testing.T) {
+		watcher.OnUpgradePlan(chainID, &upgrade.Plan{Name: "v15", Height: 100})
+
+		// Before the upgrade height
+		watcher.handleNodeVersion(chainID, nodeA, 95, NodeVersion{AppVersion: "v14.2.1"})
+		watcher.handleNodeVersion(chainID, nodeB, 95, NodeVersion{AppVersion: "v15.0.0"})
+		assert.Equal(t, float64(0), testutil.ToFloat64(watcher.metrics.NodeUpgradeEarly.WithLabelValues(chainID, nodeA, "v15")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(watcher.metrics.NodeUpgradeEarly.WithLabelValues(chainID, nodeB, "v15")))
+
+		// After the upgrade height
+		watcher.handleNodeVersion(chainID, nodeB, 101, NodeVersion{AppVersion: "v15.0.0"})
+		watcher.handleNodeVersion(chainID, nodeA, 99, NodeVersion{AppVersion: "v14.2.1"})
+		assert.Equal(t, float64(0), testutil.ToFloat64(watcher.metrics.NodeUpgradeOutdated.WithLabelValues(chainID, nodeB, "v15")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(watcher.metrics.NodeUpgradeOutdated.WithLabelValues(chainID, nodeA, "v15")))
+
+		// Plan is kept once applied
+		watcher.OnUpgradePlan(chainID, nil)
+		watcher.handleNodeVersion(chainID, nodeA, 99, NodeVersion{AppVersion: "v15.0.0"})
+		assert.Equal(t, float64(0), testutil.ToFloat64(watcher.metrics.NodeUpgradeOutdated.WithLabelValues(chainID, nodeA, "v15")))
+	})
+
+	t.Run("Handle Node Network", func(t *testing.T) {
+		watcher.handleNetInfo(chainID, nodeA, &ctypes.ResultNetInfo{
+			Peers: []ctypes.Peer{{IsOutbound: true}, {IsOutbound: false}, {IsOutbound: true}},
+		})
+		watcher.handleMempool(chainID, nodeA, &ctypes.ResultUnconfirmedTxs{Total: 12, TotalBytes: 4096})
+
+		now := time.Now()
+		watcher.handleClockDrift(chainID, nodeA, now.Add(-3*time.Second), now)
+
+		assert.Equal(t, float64(1), testutil.ToFloat64(watcher.metrics.NodePeers.WithLabelValues(chainID, nodeA, "inbound")))
+		assert.Equal(t, float64(2), testutil.ToFloat64(watcher.metrics.NodePeers.WithLabelValues(chainID, nodeA, "outbound")))
+		assert.Equal(t, float64(12), testutil.ToFloat64(watcher.metrics.NodeMempoolTxs.WithLabelValues(chainID, nodeA)))
+		assert.Equal(t, float64(4096), testutil.ToFloat64(watcher.metrics.NodeMempoolBytes.WithLabelValues(chainID, nodeA)))
+		assert.Equal(t, float64(3), testutil.ToFloat64(watcher.metrics.NodeClockDrift.WithLabelValues(chainID, nodeA)))
+	})
+
+	t.Run("Handle Node Quarantine", func(t *testing.T) {
+		watcher.handleNodeQuarantine(chainID, nodeA, []string{rpc.QuarantineChainIDMismatch})
+		assert.Equal(t, float64(1), testutil.ToFloat64(watcher.metrics.NodeQuarantined.WithLabelValues(chainID, nodeA, rpc.QuarantineChainIDMismatch)))
+
+		watcher.handleNodeQuarantine(chainID, nodeA, nil)
+		assert.Equal(t, float64(0), testutil.ToFloat64(watcher.metrics.NodeQuarantined.WithLabelValues(chainID, nodeA, rpc.QuarantineChainIDMismatch)))
+	})
+
+	t.Run("Handle Node Lag", func(t *testing.T) {
+		watcher.handleNodeLag(chainID, nodeB, 30, true)
+		assert.Equal(t, float64(30), testutil.ToFloat64(watcher.metrics.NodeLag.WithLabelValues(chainID, nodeB)))
+		assert.Equal(t, true, watcher.laggingNodes[nodeB])
+
+		watcher.handleNodeLag(chainID, nodeB, 0, false)
+		assert.Equal(t, float64(0), testutil.ToFloat64(watcher.metrics.NodeLag.WithLabelValues(chainID, nodeB)))
+		assert.Equal(t, false, watcher.laggingNodes[nodeB])
+	})
+
+	t.Run("Handle Node Divergence", func(t *testing.T) {
+		ctx := context.Background()
+
+		watcher.handleNodeDivergence(ctx, chainID, nodeB, rpc.QuarantineForked, &rpc.Divergence{Kind: rpc.QuarantineForked, Height: 120, Expected: "AAAA", Actual: "BBBB"})
+		assert.Equal(t, float64(120), testutil.ToFloat64(watcher.metrics.NodeDivergence.WithLabelValues(chainID, nodeB, rpc.QuarantineForked)))
+
+		watcher.handleNodeDivergence(ctx, chainID, nodeB, rpc.QuarantineForked, nil)
+		assert.Equal(t, float64(0), testutil.ToFloat64(watcher.metrics.NodeDivergence.WithLabelValues(chainID, nodeB, rpc.QuarantineForked)))
+	})
+}
+
+func TestVersionMatchesPlan(t *testing.T) {
+	assert.Equal(t, true, versionMatchesPlan("v15.0.0", "v15"))
+	assert.Equal(t, true, versionMatchesPlan("15.0.0-rc1", "v15.0.0"))
+	assert.Equal(t, false, versionMatchesPlan("v150.0.0", "v15"))
+	assert.Equal(t, false, versionMatchesPlan("v14.2.0", "v15"))
+	assert.Equal(t, false, versionMatchesPlan("", "v15"))
+}
@@ -0,0 +1,188 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/rpc"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/webhook"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	ValidatorNodeRoleValidator = "validator"
+	ValidatorNodeRoleSentry    = "sentry"
+)
+
+// ValidatorNode is a CometBFT node (validator or sentry) operated for a tracked validator.
+type ValidatorNode struct {
+	Validator string // address or name of the tracked validator
+	Role      string
+	ID        string
+}
+
+// ParseValidatorNode parses a `<validator>:<role>:<node-id>` value.
+func ParseValidatorNode(val string) (ValidatorNode, error) {
+	parts := strings.Split(val, ":")
+	if len(parts) != 3 {
+		return ValidatorNode{}, fmt.Errorf("invalid validator node (expected <validator>:<role>:<node-id>): %s", val)
+	}
+
+	role := strings.ToLower(parts[1])
+	if role != ValidatorNodeRoleValidator && role != ValidatorNodeRoleSentry {
+		return ValidatorNode{}, fmt.Errorf("invalid validator node role (expected validator or sentry): %s", parts[1])
+	}
+
+	return ValidatorNode{
+		Validator: parts[0],
+		Role:      role,
+		ID:        strings.ToLower(parts[2]),
+	}, nil
+}
+
+type TopologyWatcher struct {
+	metrics *metrics.Metrics
+	webhook *webhook.Webhook
+	nodes   []trackedValidatorNode
+
+	mu        sync.Mutex
+	connected map[string]bool // connection state by observing node & peer id
+}
+
+type trackedValidatorNode struct {
+	ValidatorNode
+	validator TrackedValidator
+}
+
+func NewTopologyWatcher(validators []TrackedValidator, nodes []ValidatorNode, metrics *metrics.Metrics, webhook *webhook.Webhook) *TopologyWatcher {
+	trackedNodes := make([]trackedValidatorNode, 0, len(nodes))
+	for _, node := range nodes {
+		validator := TrackedValidator{Address: node.Validator, Name: node.Validator}
+		found := false
+		for _, val := range validators {
+			if val.Address == node.Validator || val.Name == node.Validator {
+				validator = val
+				found = true
+				break
+			}
+		}
+		if !found {
+			log.Warn().Str("validator", node.Validator).Msgf("validator node %s does not match any tracked validator", node.ID)
+		}
+		trackedNodes = append(trackedNodes, trackedValidatorNode{
+			ValidatorNode: node,
+			validator:     validator,
+		})
+	}
+
+	return &TopologyWatcher{
+		metrics:   metrics,
+		webhook:   webhook,
+		nodes:     trackedNodes,
+		connected: make(map[string]bool),
+	}
+}
+
+func (w *TopologyWatcher) OnNodeNetInfo(ctx context.Context, n *rpc.Node, status *ctypes.ResultStatus, netInfo *ctypes.ResultNetInfo) error {
+	w.handleNetInfo(ctx, status.NodeInfo.Network, n.Endpoint(), string(status.NodeInfo.ID()), netInfo)
+	return nil
+}
+
+func (w *TopologyWatcher) handleNetInfo(ctx context.Context, chainID string, endpoint string, nodeID string, netInfo *ctypes.ResultNetInfo) {
+	peers := make(map[string]ctypes.Peer)
+	for _, peer := range netInfo.Peers {
+		peers[strings.ToLower(string(peer.NodeInfo.ID()))] = peer
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, expected := range w.nodes {
+		// A node is not its own peer
+		if expected.ID == strings.ToLower(nodeID) {
+			continue
+		}
+
+		peer, connected := peers[expected.ID]
+		labels := []string{chainID, expected.validator.Address, expected.validator.Name, endpoint, expected.ID, expected.Role}
+
+		w.metrics.ValidatorPeerConnected.WithLabelValues(labels...).Set(metrics.BoolToFloat64(connected))
+		if connected {
+			w.metrics.ValidatorPeerConnectedDuration.WithLabelValues(labels...).Set(peer.ConnectionStatus.Duration.Seconds())
+		} else {
+			w.metrics.ValidatorPeerConnectedDuration.WithLabelValues(labels...).Set(0)
+		}
+
+		// Only sentries of the validator are expected to be connected to its validator node
+		if expected.Role != ValidatorNodeRoleValidator || !w.isSentryOf(nodeID, expected.validator) {
+			continue
+		}
+
+		key := endpoint + "/" + expected.ID
+		wasConnected, known := w.connected[key]
+		w.connected[key] = connected
+
+		if !known || wasConnected == connected {
+			continue
+		}
+
+		w.handleSentryConnection(ctx, chainID, endpoint, nodeID, expected, connected)
+	}
+}
+
+func (w *TopologyWatcher) isSentryOf(nodeID string, validator TrackedValidator) bool {
+	for _, node := range w.nodes {
+		if node.Role == ValidatorNodeRoleSentry && node.ID == strings.ToLower(nodeID) && node.validator.Address == validator.Address {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *TopologyWatcher) handleSentryConnection(ctx context.Context, chainID string, endpoint string, sentryID string, validatorNode trackedValidatorNode, connected bool) {
+	eventType := "sentry_reconnected"
+	logger := log.Info()
+	if !connected {
+		eventType = "sentry_disconnected"
+		logger = log.Error()
+	}
+
+	logger.
+		Str("validator", validatorNode.validator.Name).
+		Str("node", endpoint).
+		Str("sentry", sentryID).
+		Str("validator-node", validatorNode.ID).
+		Msgf("sentry connection to validator node changed: %s", eventType)
+
+	if w.webhook == nil {
+		return
+	}
+
+	msg := struct {
+		Type            string `json:"type"`
+		ChainID         string `json:"chain_id"`
+		Address         string `json:"address"`
+		Name            string `json:"name"`
+		Node            string `json:"node"`
+		SentryID        string `json:"sentry_id"`
+		ValidatorNodeID string `json:"validator_node_id"`
+	}{
+		Type:            eventType,
+		ChainID:         chainID,
+		Address:         validatorNode.validator.Address,
+		Name:            validatorNode.validator.Name,
+		Node:            endpoint,
+		SentryID:        sentryID,
+		ValidatorNodeID: validatorNode.ID,
+	}
+
+	go func() {
+		if err := w.webhook.Send(ctx, msg); err != nil {
+			log.Error().Err(err).Msgf("failed to send %s webhook", eventType)
+		}
+	}()
+}
@@ -0,0 +1,76 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cometbft/cometbft/p2p"
+	"github.com/cometbft/cometbft/p2p/conn"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestTopologyWatcher(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		kilnAddress = "3DC4DD610817606AD4A8F9D762A068A81E8741E2"
+		kilnName    = "Kiln"
+		chainID     = "chain-42"
+		sentry      = "http://sentry-1:26657"
+		sentryID    = "aaaa"
+		validatorID = "bbbb"
+	)
+
+	nodes := []ValidatorNode{}
+	for _, val := range []string{"Kiln:sentry:AAAA", "Kiln:validator:bbbb"} {
+		node, err := ParseValidatorNode(val)
+		assert.NilError(t, err)
+		nodes = append(nodes, node)
+	}
+
+	watcher := NewTopologyWatcher(
+		[]TrackedValidator{{Address: kilnAddress, Name: kilnName}},
+		nodes,
+		metrics.New("cosmos_validator_watcher"),
+		nil,
+	)
+
+	peer := func(id string, duration time.Duration) ctypes.Peer {
+		return ctypes.Peer{
+			NodeInfo:         p2p.DefaultNodeInfo{DefaultNodeID: p2p.ID(id)},
+			ConnectionStatus: conn.ConnectionStatus{Duration: duration},
+		}
+	}
+
+	t.Run("Handle Connected Validator", func(t *testing.T) {
+		watcher.handleNetInfo(ctx, chainID, sentry, sentryID, &ctypes.ResultNetInfo{
+			Peers: []ctypes.Peer{peer("cccc", time.Minute), peer(validatorID, 2*time.Hour)},
+		})
+
+		// The sentry is not its own peer
+		assert.Equal(t, 1, testutil.CollectAndCount(watcher.metrics.ValidatorPeerConnected))
+		assert.Equal(t, float64(1), testutil.ToFloat64(watcher.metrics.ValidatorPeerConnected.WithLabelValues(chainID, kilnAddress, kilnName, sentry, validatorID, "validator")))
+		assert.Equal(t, float64(7200), testutil.ToFloat64(watcher.metrics.ValidatorPeerConnectedDuration.WithLabelValues(chainID, kilnAddress, kilnName, sentry, validatorID, "validator")))
+		assert.Equal(t, true, watcher.connected[sentry+"/"+validatorID])
+	})
+
+	t.Run("Handle Disconnected Validator", func(t *testing.T) {
+		watcher.handleNetInfo(ctx, chainID, sentry, sentryID, &ctypes.ResultNetInfo{
+			Peers: []ctypes.Peer{peer("cccc", time.Minute)},
+		})
+
+		assert.Equal(t, float64(0), testutil.ToFloat64(watcher.metrics.ValidatorPeerConnected.WithLabelValues(chainID, kilnAddress, kilnName, sentry, validatorID, "validator")))
+		assert.Equal(t, false, watcher.connected[sentry+"/"+validatorID])
+	})
+
+	t.Run("Parse Invalid Validator Node", func(t *testing.T) {
+		_, err := ParseValidatorNode("Kiln:archive:aaaa")
+		assert.ErrorContains(t, err, "invalid validator node role")
+
+		_, err = ParseValidatorNode("Kiln:aaaa")
+		assert.ErrorContains(t, err, "invalid validator node")
+	})
+}
@@ -3,6 +3,9 @@ package watcher
 import (
 	"context"
 	"fmt"
+	"io"
+	"strings"
+	"sync"
 	"time"
 
 	upgrade "cosmossdk.io/x/upgrade/types"
@@ -13,6 +16,7 @@ import (
 	query "github.com/cosmos/cosmos-sdk/types/query"
 	gov "github.com/cosmos/cosmos-sdk/x/gov/types/v1"
 	govbeta "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
+	"github.com/fatih/color"
 	"github.com/gogo/protobuf/codec"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/rpc"
@@ -24,27 +28,72 @@ type UpgradeWatcher struct {
 	metrics *metrics.Metrics
 	pool    *rpc.Pool
 	webhook *webhook.Webhook
+	writer  io.Writer
 	options UpgradeWatcherOptions
 
-	nextUpgradePlan   *upgrade.Plan // known upgrade plan
-	latestBlockHeight int64         // latest block received
-	latestWebhookSent int64         // latest block for which webhook has been sent
+	blockTimes *BlockTimes // recent block times used to estimate the upgrade time
+	onPlan     []OnUpgradePlan
+
+	// State shared by the fetch loop and the blocks handler
+	mu                sync.RWMutex
+	plan              *upgrade.Plan     // latest fetched plan (kept after the webhook is sent)
+	nextUpgradePlan   *upgrade.Plan     // known upgrade plan
+	latestBlockHeight int64             // latest block received
+	latestWebhookSent int64             // latest block for which webhook has been sent
+	upgrades          []UpgradeProposal // all tracked upgrades (on-chain & proposals)
+	printedPlan       string            // latest plan printed (by name & height)
+
+	remindersMu   sync.Mutex
+	remindersSent map[string]bool // reminders already sent (by plan & lead time)
+
+	infosMu      sync.Mutex
+	invalidInfos map[string]bool // plans with a malformed info already reported
+
+	health *Health
+}
+
+type OnUpgradePlan func(chainID string, plan *upgrade.Plan)
+
+const UpgradeStatusCurrent = "current"
+
+// UpgradeProposal is an upgrade tracked by the watcher: either the plan
+// currently scheduled on-chain or a governance proposal to upgrade (or cancel).
+type UpgradeProposal struct {
+	ProposalID uint64 // 0 for the on-chain plan
+	Status     string // "current" for the on-chain plan, the proposal status otherwise
+	Cancel     bool   // proposal to cancel the on-chain plan
+	Plan       upgrade.Plan
 }
 
 type UpgradeWatcherOptions struct {
 	CheckPendingProposals bool
 	GovModuleVersion      string
+	ReminderLeadTimes     []time.Duration
 }
 
-func NewUpgradeWatcher(metrics *metrics.Metrics, pool *rpc.Pool, webhook *webhook.Webhook, options UpgradeWatcherOptions) *UpgradeWatcher {
+func NewUpgradeWatcher(metrics *metrics.Metrics, pool *rpc.Pool, webhook *webhook.Webhook, writer io.Writer, options UpgradeWatcherOptions) *UpgradeWatcher {
 	return &UpgradeWatcher{
-		metrics: metrics,
-		pool:    pool,
-		webhook: webhook,
-		options: options,
+		metrics:       metrics,
+		pool:          pool,
+		webhook:       webhook,
+		writer:        writer,
+		options:       options,
+		blockTimes:    NewBlockTimes(100),
+		remindersSent: make(map[string]bool),
+		invalidInfos:  make(map[string]bool),
+		health:        NewHealth("upgrade", metrics),
 	}
 }
 
+func (w *UpgradeWatcher) Health() *Health {
+	return w.health
+}
+
+// OnUpgradePlan registers a callback called each time the upgrade plan is fetched.
+func (w *UpgradeWatcher) OnUpgradePlan(callback OnUpgradePlan) {
+	w.onPlan = append(w.onPlan, callback)
+}
+
 func (w *UpgradeWatcher) Start(ctx context.Context) error {
 	ticker := time.NewTicker(1 * time.Minute)
 
@@ -52,11 +101,16 @@ func (w *UpgradeWatcher) Start(ctx context.Context) error {
 		node := w.pool.GetSyncedNode()
 		if node == nil {
 			log.Warn().Msg("no node available to fetch upgrade plan")
+			w.health.Report(errNoSyncedNode)
 		} else if err := w.fetchUpgrade(ctx, node); err != nil {
 			log.Error().Err(err).
 				Str("node", node.Redacted()).
 				Msg("failed to fetch upgrade plan")
+			w.health.Report(err)
+		} else {
+			w.health.Report(nil)
 		}
+		w.health.Processed(0)
 
 		select {
 		case <-ctx.Done():
@@ -70,20 +124,27 @@ func (w *UpgradeWatcher) OnNewBlock(ctx context.Context, node *rpc.Node, evt *ct
 	blockEvent := evt.Data.(comettypes.EventDataNewBlock)
 	block := blockEvent.Block
 
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	// Skip already processed blocks
 	if w.latestBlockHeight >= block.Height {
 		return nil
 	}
 
 	w.latestBlockHeight = block.Height
+	w.blockTimes.Add(block.Height, block.Time)
 
-	// Ignore is webhook is not configured
-	if w.webhook == nil {
+	// Ignore if no upgrade plan
+	if w.nextUpgradePlan == nil {
 		return nil
 	}
 
-	// Ignore if no upgrade plan
-	if w.nextUpgradePlan == nil {
+	// Refresh the estimated upgrade time
+	w.handleUpgradeETA(node.ChainID(), *w.nextUpgradePlan)
+
+	// Ignore is webhook is not configured
+	if w.webhook == nil {
 		return nil
 	}
 
@@ -92,6 +153,9 @@ func (w *UpgradeWatcher) OnNewBlock(ctx context.Context, node *rpc.Node, evt *ct
 		return nil
 	}
 
+	// Send reminders ahead of the upgrade
+	w.handleReminders(ctx, node.ChainID(), *w.nextUpgradePlan)
+
 	// Ignore if upgrade plan is for a future block
 	if w.latestBlockHeight < w.nextUpgradePlan.Height-1 {
 		return nil
@@ -111,24 +175,140 @@ func (w *UpgradeWatcher) OnNewBlock(ctx context.Context, node *rpc.Node, evt *ct
 }
 
 func (w *UpgradeWatcher) triggerWebhook(ctx context.Context, chainID string, plan upgrade.Plan) {
-	msg := struct {
-		Type    string `json:"type"`
-		Block   int64  `json:"block"`
-		ChainID string `json:"chain_id"`
-		Version string `json:"version"`
-	}{
-		Type:    "upgrade",
-		Block:   plan.Height,
-		ChainID: chainID,
-		Version: plan.Name,
-	}
+	msg := w.upgradeMessage("upgrade", chainID, plan)
 
 	if err := w.webhook.Send(ctx, msg); err != nil {
 		log.Error().Err(err).Msg("failed to send upgrade webhook")
 	}
 }
 
+func (w *UpgradeWatcher) triggerReminderWebhook(ctx context.Context, chainID string, plan upgrade.Plan, lead time.Duration) {
+	msg := w.upgradeMessage("upgrade_reminder", chainID, plan)
+	msg.LeadTime = lead.String()
+
+	if err := w.webhook.Send(ctx, msg); err != nil {
+		log.Error().Err(err).Msg("failed to send upgrade reminder webhook")
+	}
+}
+
+type upgradeMessage struct {
+	Type       string          `json:"type"`
+	Block      int64           `json:"block"`
+	ChainID    string          `json:"chain_id"`
+	Version    string          `json:"version"`
+	ETA        *time.Time      `json:"eta,omitempty"`
+	ETASeconds *float64        `json:"eta_seconds,omitempty"`
+	LeadTime   string          `json:"lead_time,omitempty"`
+	Binaries   []UpgradeBinary `json:"binaries,omitempty"`
+}
+
+func (w *UpgradeWatcher) upgradeMessage(msgType string, chainID string, plan upgrade.Plan) upgradeMessage {
+	msg := upgradeMessage{
+		Type:     msgType,
+		Block:    plan.Height,
+		ChainID:  chainID,
+		Version:  plan.Name,
+		Binaries: w.parseUpgradeInfo(plan).SortedBinaries(),
+	}
+
+	if eta, ok := w.blockTimes.EstimateTime(plan.Height); ok {
+		seconds := etaSeconds(eta)
+		msg.ETA = &eta
+		msg.ETASeconds = &seconds
+	}
+
+	return msg
+}
+
+func (w *UpgradeWatcher) handleReminders(ctx context.Context, chainID string, plan upgrade.Plan) {
+	eta, ok := w.blockTimes.EstimateTime(plan.Height)
+	if !ok {
+		return
+	}
+
+	// Only the closest lead time already reached is reminded (eg. a single
+	// reminder when starting shortly before the upgrade)
+	var (
+		remaining = time.Until(eta)
+		lead      time.Duration
+		reached   bool
+	)
+	for _, l := range w.options.ReminderLeadTimes {
+		if remaining <= l && (!reached || l < lead) {
+			lead, reached = l, true
+		}
+	}
+	if !reached {
+		return
+	}
+
+	key := fmt.Sprintf("%s/%d/%s", plan.Name, plan.Height, lead)
+
+	w.remindersMu.Lock()
+	sent := w.remindersSent[key]
+	w.remindersSent[key] = true
+	w.remindersMu.Unlock()
+
+	if sent {
+		return
+	}
+
+	log.Info().
+		Str("version", plan.Name).
+		Int64("height", plan.Height).
+		Str("lead", lead.String()).
+		Msg("sending upgrade reminder")
+
+	go w.triggerReminderWebhook(ctx, chainID, plan, lead)
+}
+
+func (w *UpgradeWatcher) handleUpgradeETA(chainID string, plan upgrade.Plan) {
+	eta, ok := w.blockTimes.EstimateTime(plan.Height)
+	if !ok {
+		return
+	}
+
+	w.metrics.UpgradeETA.WithLabelValues(chainID, plan.Name, fmt.Sprintf("%d", plan.Height)).Set(etaSeconds(eta))
+}
+
+// printUpgradePlan prints the plan once, until it changes.
+func (w *UpgradeWatcher) printUpgradePlan(plan upgrade.Plan) {
+	if w.writer == nil {
+		return
+	}
+
+	key := fmt.Sprintf("%s/%d", plan.Name, plan.Height)
+	w.mu.Lock()
+	printed := key == w.printedPlan
+	w.printedPlan = key
+	w.mu.Unlock()
+	if printed {
+		return
+	}
+
+	eta := "unknown ETA"
+	if t, ok := w.blockTimes.EstimateTime(plan.Height); ok {
+		eta = fmt.Sprintf("ETA %s (in %s)", t.UTC().Format(time.RFC3339), time.Until(t).Round(time.Minute))
+	}
+
+	fmt.Fprintln(
+		w.writer,
+		color.YellowString(fmt.Sprintf("#%d", plan.Height)),
+		color.MagentaString(fmt.Sprintf("upgrade %s", plan.Name)),
+		eta,
+	)
+}
+
+func etaSeconds(eta time.Time) float64 {
+	seconds := time.Until(eta).Seconds()
+	if seconds < 0 {
+		return 0
+	}
+	return seconds
+}
+
 func (w *UpgradeWatcher) fetchUpgrade(ctx context.Context, node *rpc.Node) error {
+	// Not cached, to act on the latest plan state
 	clientCtx := (client.Context{}).WithClient(node.Client)
 	queryClient := upgrade.NewQueryClient(clientCtx)
 
@@ -137,27 +317,44 @@ func (w *UpgradeWatcher) fetchUpgrade(ctx context.Context, node *rpc.Node) error
 		return err
 	}
 
-	plan := resp.Plan
+	upgrades := []UpgradeProposal{}
+	if resp.Plan != nil {
+		upgrades = append(upgrades, UpgradeProposal{
+			Status: UpgradeStatusCurrent,
+			Plan:   *resp.Plan,
+		})
+	}
 
-	if plan == nil && w.options.CheckPendingProposals {
+	if w.options.CheckPendingProposals {
+		var proposals []UpgradeProposal
 		switch w.options.GovModuleVersion {
 		case "v1beta1":
-			plan, err = w.checkUpgradeProposalsV1Beta1(ctx, node)
+			proposals, err = w.checkUpgradeProposalsV1Beta1(ctx, node)
 		default: // v1
-			plan, err = w.checkUpgradeProposalsV1(ctx, node)
+			proposals, err = w.checkUpgradeProposalsV1(ctx, node)
 		}
 		if err != nil {
 			log.Error().Err(err).Msg("failed to check upgrade proposals")
 		}
+		upgrades = append(upgrades, proposals...)
 	}
 
+	plan := w.handleUpgrades(node.ChainID(), upgrades)
 	w.handleUpgradePlan(node.ChainID(), plan)
 
+	if plan != nil {
+		w.printUpgradePlan(*plan)
+	} else {
+		w.mu.Lock()
+		w.printedPlan = ""
+		w.mu.Unlock()
+	}
+
 	return nil
 }
 
-func (w *UpgradeWatcher) checkUpgradeProposalsV1(ctx context.Context, node *rpc.Node) (*upgrade.Plan, error) {
-	clientCtx := (client.Context{}).WithClient(node.Client)
+func (w *UpgradeWatcher) checkUpgradeProposalsV1(ctx context.Context, node *rpc.Node) ([]UpgradeProposal, error) {
+	clientCtx := (client.Context{}).WithClient(node.QueryClient())
 	queryClient := gov.NewQueryClient(clientCtx)
 
 	// Fetch all proposals in voting period
@@ -172,23 +369,25 @@ func (w *UpgradeWatcher) checkUpgradeProposalsV1(ctx context.Context, node *rpc.
 		return nil, fmt.Errorf("failed to get proposals: %w", err)
 	}
 
+	upgrades := []UpgradeProposal{}
 	for _, proposal := range proposalsResp.GetProposals() {
+		status := proposalStatusName(proposal.Status.String())
 		for _, message := range proposal.Messages {
-			plan, err := extractUpgradePlan(message)
+			upgrade, err := w.extractUpgradeProposal(proposal.Id, status, message)
 			if err != nil {
 				return nil, fmt.Errorf("failed to extract upgrade plan: %w", err)
 			}
-			if plan != nil && plan.Height > w.latestBlockHeight {
-				return plan, nil
+			if upgrade != nil {
+				upgrades = append(upgrades, *upgrade)
 			}
 		}
 	}
 
-	return nil, nil
+	return upgrades, nil
 }
 
-func (w *UpgradeWatcher) checkUpgradeProposalsV1Beta1(ctx context.Context, node *rpc.Node) (*upgrade.Plan, error) {
-	clientCtx := (client.Context{}).WithClient(node.Client)
+func (w *UpgradeWatcher) checkUpgradeProposalsV1Beta1(ctx context.Context, node *rpc.Node) ([]UpgradeProposal, error) {
+	clientCtx := (client.Context{}).WithClient(node.QueryClient())
 	queryClient := govbeta.NewQueryClient(clientCtx)
 
 	// Fetch all proposals in voting period
@@ -199,17 +398,49 @@ func (w *UpgradeWatcher) checkUpgradeProposalsV1Beta1(ctx context.Context, node
 		return nil, fmt.Errorf("failed to get proposals: %w", err)
 	}
 
+	upgrades := []UpgradeProposal{}
 	for _, proposal := range proposalsResp.GetProposals() {
-		plan, err := extractUpgradePlan(proposal.Content)
+		status := proposalStatusName(proposal.Status.String())
+		upgrade, err := w.extractUpgradeProposal(proposal.ProposalId, status, proposal.Content)
 		if err != nil {
 			return nil, fmt.Errorf("failed to extract upgrade plan: %w", err)
 		}
-		if plan != nil && plan.Height > w.latestBlockHeight {
-			return plan, nil
+		if upgrade != nil {
+			upgrades = append(upgrades, *upgrade)
 		}
 	}
 
-	return nil, nil
+	return upgrades, nil
+}
+
+func (w *UpgradeWatcher) extractUpgradeProposal(proposalID uint64, status string, content *codectypes.Any) (*UpgradeProposal, error) {
+	if isCancelUpgrade(content) {
+		return &UpgradeProposal{
+			ProposalID: proposalID,
+			Status:     status,
+			Cancel:     true,
+		}, nil
+	}
+
+	plan, err := extractUpgradePlan(content)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	latestBlockHeight := w.latestBlockHeight
+	w.mu.RUnlock()
+
+	// Ignore plans for past blocks
+	if plan == nil || plan.Height <= latestBlockHeight {
+		return nil, nil
+	}
+
+	return &UpgradeProposal{
+		ProposalID: proposalID,
+		Status:     status,
+		Plan:       *plan,
+	}, nil
 }
 
 func extractUpgradePlan(content *codectypes.Any) (*upgrade.Plan, error) {
@@ -240,12 +471,141 @@ func extractUpgradePlan(content *codectypes.Any) (*upgrade.Plan, error) {
 	return nil, nil
 }
 
+func isCancelUpgrade(content *codectypes.Any) bool {
+	if content == nil {
+		return false
+	}
+
+	switch content.TypeUrl {
+	case "/cosmos.upgrade.v1beta1.CancelSoftwareUpgradeProposal",
+		"/cosmos.upgrade.v1beta1.MsgCancelUpgrade":
+		return true
+	}
+
+	return false
+}
+
+// parseUpgradeInfo decodes the plan info, reporting malformed info only once per plan.
+func (w *UpgradeWatcher) parseUpgradeInfo(plan upgrade.Plan) *UpgradeInfo {
+	info, err := ParseUpgradeInfo(plan.Info)
+	if err != nil {
+		// Plans are parsed by both the fetch loop and the webhooks
+		w.infosMu.Lock()
+		defer w.infosMu.Unlock()
+
+		key := fmt.Sprintf("%s/%d", plan.Name, plan.Height)
+		if !w.invalidInfos[key] {
+			w.invalidInfos[key] = true
+			log.Warn().Err(err).
+				Str("version", plan.Name).
+				Int64("height", plan.Height).
+				Str("info", plan.Info).
+				Msg("malformed upgrade plan info (expected cosmovisor json format)")
+		}
+		return nil
+	}
+	return info
+}
+
+// proposalStatusName converts PROPOSAL_STATUS_VOTING_PERIOD into voting_period.
+func proposalStatusName(status string) string {
+	return strings.ToLower(strings.TrimPrefix(status, "PROPOSAL_STATUS_"))
+}
+
+// handleUpgrades exports all tracked upgrades and returns the plan expected to
+// be applied next: the on-chain plan if any, otherwise the latest proposal.
+func (w *UpgradeWatcher) handleUpgrades(chainID string, upgrades []UpgradeProposal) *upgrade.Plan {
+	var current, next *upgrade.Plan
+	var nextProposalID uint64
+	for _, u := range upgrades {
+		u := u
+		if u.Status == UpgradeStatusCurrent {
+			current = &u.Plan
+		} else if !u.Cancel && (next == nil || u.ProposalID > nextProposalID) {
+			next = &u.Plan
+			nextProposalID = u.ProposalID
+		}
+	}
+
+	w.mu.Lock()
+	w.upgrades = upgrades
+	w.mu.Unlock()
+
+	w.metrics.UpgradeProposal.Reset()
+	for _, u := range upgrades {
+		var (
+			proposalID = ""
+			action     = "upgrade"
+			plan       = u.Plan
+		)
+		if u.ProposalID > 0 {
+			proposalID = fmt.Sprintf("%d", u.ProposalID)
+		}
+		if u.Cancel {
+			action = "cancel"
+			// A cancel proposal targets the on-chain plan
+			if current == nil {
+				plan = upgrade.Plan{}
+			} else {
+				plan = *current
+			}
+		}
+
+		w.metrics.UpgradeProposal.
+			WithLabelValues(chainID, proposalID, u.Status, action, plan.Name, fmt.Sprintf("%d", plan.Height)).
+			Set(float64(plan.Height))
+	}
+
+	// Export binaries advertised in the plans info
+	w.metrics.UpgradeBinary.Reset()
+	for _, u := range upgrades {
+		if u.Cancel {
+			continue
+		}
+		for _, binary := range w.parseUpgradeInfo(u.Plan).SortedBinaries() {
+			w.metrics.UpgradeBinary.
+				WithLabelValues(chainID, u.Plan.Name, fmt.Sprintf("%d", u.Plan.Height), binary.Platform, binary.URL, binary.Checksum).
+				Set(1)
+		}
+	}
+
+	if current != nil {
+		return current
+	}
+	return next
+}
+
+// Upgrade returns the next upgrade plan (nil if none).
+func (w *UpgradeWatcher) Upgrade() *UpgradeState {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.plan == nil {
+		return nil
+	}
+
+	state := &UpgradeState{Name: w.plan.Name, Height: w.plan.Height, Info: w.plan.Info}
+	if eta, ok := w.blockTimes.EstimateTime(w.plan.Height); ok {
+		state.ETA = &eta
+	}
+	return state
+}
+
 func (w *UpgradeWatcher) handleUpgradePlan(chainID string, plan *upgrade.Plan) {
+	w.mu.Lock()
 	w.nextUpgradePlan = plan
+	w.plan = plan
+	w.mu.Unlock()
 
+	w.metrics.UpgradeETA.Reset()
 	if plan == nil {
 		w.metrics.UpgradePlan.Reset()
 	} else {
 		w.metrics.UpgradePlan.WithLabelValues(chainID, plan.Name, fmt.Sprintf("%d", plan.Height)).Set(float64(plan.Height))
+		w.handleUpgradeETA(chainID, *plan)
+	}
+
+	for _, onPlan := range w.onPlan {
+		onPlan(chainID, plan)
 	}
 }
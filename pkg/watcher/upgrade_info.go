@@ -0,0 +1,70 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// UpgradeInfo is the content of an upgrade plan info field, following the
+// Cosmovisor format:
+//
+//	{"binaries": {"linux/amd64": "https://example.com/gaiad?checksum=sha256:..."}}
+type UpgradeInfo struct {
+	Binaries map[string]string `json:"binaries"`
+}
+
+type UpgradeBinary struct {
+	Platform string `json:"platform"`
+	URL      string `json:"url"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// ParseUpgradeInfo decodes a plan info field. It returns nil (without error)
+// when the info is empty or is not JSON (eg. a free text or a link to a file).
+func ParseUpgradeInfo(info string) (*UpgradeInfo, error) {
+	info = strings.TrimSpace(info)
+	if !strings.HasPrefix(info, "{") {
+		return nil, nil
+	}
+
+	var upgradeInfo UpgradeInfo
+	if err := json.Unmarshal([]byte(info), &upgradeInfo); err != nil {
+		return nil, fmt.Errorf("failed to decode upgrade info: %w", err)
+	}
+
+	for platform, binaryURL := range upgradeInfo.Binaries {
+		if _, err := url.ParseRequestURI(binaryURL); err != nil {
+			return nil, fmt.Errorf("invalid binary url for %s: %w", platform, err)
+		}
+	}
+
+	return &upgradeInfo, nil
+}
+
+// SortedBinaries returns the advertised binaries, sorted by platform.
+func (i *UpgradeInfo) SortedBinaries() []UpgradeBinary {
+	if i == nil {
+		return nil
+	}
+
+	binaries := make([]UpgradeBinary, 0, len(i.Binaries))
+	for platform, binaryURL := range i.Binaries {
+		binary := UpgradeBinary{
+			Platform: platform,
+			URL:      binaryURL,
+		}
+		if u, err := url.Parse(binaryURL); err == nil {
+			binary.Checksum = u.Query().Get("checksum")
+		}
+		binaries = append(binaries, binary)
+	}
+
+	sort.Slice(binaries, func(i, j int) bool {
+		return binaries[i].Platform < binaries[j].Platform
+	})
+
+	return binaries
+}
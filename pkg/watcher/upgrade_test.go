@@ -1,10 +1,21 @@
 package watcher
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	upgrade "cosmossdk.io/x/upgrade/types"
+	ctypes "github.com/cometbft/cometbft/rpc/core/types"
+	comettypes "github.com/cometbft/cometbft/types"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/rpc"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/webhook"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"gotest.tools/assert"
 )
@@ -16,6 +27,7 @@ func TestUpgradeWatcher(t *testing.T) {
 		metrics.New("cosmos_validator_watcher"),
 		nil,
 		nil,
+		&bytes.Buffer{},
 		UpgradeWatcherOptions{},
 	)
 
@@ -38,4 +50,165 @@ func TestUpgradeWatcher(t *testing.T) {
 
 		assert.Equal(t, 0, testutil.CollectAndCount(watcher.metrics.UpgradePlan))
 	})
+
+	t.Run("Handle Upgrade ETA", func(t *testing.T) {
+		now := time.Now()
+		for i := int64(0); i < 10; i++ {
+			watcher.blockTimes.Add(1000+i, now.Add(time.Duration(i-9)*6*time.Second))
+		}
+
+		watcher.handleUpgradePlan(chainID, &upgrade.Plan{
+			Name:   "v43.0.0",
+			Height: 1109,
+		})
+
+		eta := testutil.ToFloat64(watcher.metrics.UpgradeETA.WithLabelValues(chainID, "v43.0.0", "1109"))
+		assert.Assert(t, eta > 595 && eta <= 600)
+	})
+
+	t.Run("Handle Upgrade Proposals", func(t *testing.T) {
+		plan := watcher.handleUpgrades(chainID, []UpgradeProposal{
+			{ProposalID: 10, Status: "voting_period", Plan: upgrade.Plan{Name: "v44.0.0", Height: 2000}},
+			{ProposalID: 12, Status: "voting_period", Plan: upgrade.Plan{Name: "v44.1.0", Height: 2100}},
+		})
+		assert.Equal(t, "v44.1.0", plan.Name)
+		assert.Equal(t, 2, testutil.CollectAndCount(watcher.metrics.UpgradeProposal))
+
+		plan = watcher.handleUpgrades(chainID, []UpgradeProposal{
+			{Status: UpgradeStatusCurrent, Plan: upgrade.Plan{Name: "v44.0.0", Height: 2000}},
+			{ProposalID: 12, Status: "voting_period", Plan: upgrade.Plan{Name: "v44.1.0", Height: 2100}},
+			{ProposalID: 13, Status: "voting_period", Cancel: true},
+		})
+		assert.Equal(t, "v44.0.0", plan.Name)
+		assert.Equal(t, 3, testutil.CollectAndCount(watcher.metrics.UpgradeProposal))
+		assert.Equal(t, float64(2000), testutil.ToFloat64(watcher.metrics.UpgradeProposal.WithLabelValues(chainID, "", UpgradeStatusCurrent, "upgrade", "v44.0.0", "2000")))
+		assert.Equal(t, float64(2000), testutil.ToFloat64(watcher.metrics.UpgradeProposal.WithLabelValues(chainID, "13", "voting_period", "cancel", "v44.0.0", "2000")))
+
+		assert.Equal(t, true, watcher.handleUpgrades(chainID, nil) == nil)
+		assert.Equal(t, 0, testutil.CollectAndCount(watcher.metrics.UpgradeProposal))
+	})
+}
+
+func TestUpgradeReminders(t *testing.T) {
+	leads := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg upgradeMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		leads <- msg.LeadTime
+	}))
+	defer server.Close()
+
+	endpoint, err := url.Parse(server.URL)
+	assert.NilError(t, err)
+
+	watcher := NewUpgradeWatcher(
+		metrics.New("cosmos_validator_watcher"),
+		nil,
+		webhook.New(*endpoint),
+		&bytes.Buffer{},
+		UpgradeWatcherOptions{
+			ReminderLeadTimes: []time.Duration{24 * time.Hour, time.Hour, 5 * time.Minute},
+		},
+	)
+
+	// The upgrade is in about 10 minutes
+	now := time.Now()
+	for i := int64(0); i < 10; i++ {
+		watcher.blockTimes.Add(1000+i, now.Add(time.Duration(i-9)*6*time.Second))
+	}
+	plan := upgrade.Plan{Name: "v42.0.0", Height: 1109}
+
+	// Only the closest lead time reached is reminded, once
+	watcher.handleReminders(context.Background(), "chain-42", plan)
+	watcher.handleReminders(context.Background(), "chain-42", plan)
+
+	select {
+	case lead := <-leads:
+		assert.Equal(t, "1h0m0s", lead)
+	case <-time.After(5 * time.Second):
+		t.Fatal("reminder not sent")
+	}
+	select {
+	case lead := <-leads:
+		t.Fatalf("unexpected reminder: %s", lead)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestUpgradeWatcherConcurrency(t *testing.T) {
+	var chainID = "chain-42"
+
+	watcher := NewUpgradeWatcher(
+		metrics.New("cosmos_validator_watcher"),
+		nil,
+		nil,
+		&bytes.Buffer{},
+		UpgradeWatcherOptions{},
+	)
+
+	client, err := rpc.NewClient("http://localhost:26657", rpc.ClientOptions{})
+	assert.NilError(t, err)
+	node := rpc.NewNode(client)
+
+	// Blocks are handled while the plan is fetched (run with -race)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for height := int64(1); height <= 100; height++ {
+			evt := &ctypes.ResultEvent{Data: comettypes.EventDataNewBlock{Block: &comettypes.Block{
+				Header: comettypes.Header{ChainID: chainID, Height: height, Time: time.Now()},
+			}}}
+			watcher.OnNewBlock(context.Background(), node, evt)
+		}
+	}()
+
+	for i := int64(0); i < 100; i++ {
+		plan := &upgrade.Plan{Name: "v42.0.0", Height: 1000 + i%2}
+		watcher.handleUpgradePlan(chainID, watcher.handleUpgrades(chainID, []UpgradeProposal{{Status: UpgradeStatusCurrent, Plan: *plan}}))
+		watcher.printUpgradePlan(*plan)
+		_, err := watcher.extractUpgradeProposal(uint64(i), "voting_period", nil)
+		assert.NilError(t, err)
+	}
+	<-done
+
+	assert.Equal(t, int64(100), watcher.latestBlockHeight)
+}
+
+func TestBlockTimes(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	blockTimes := NewBlockTimes(3)
+
+	_, ok := blockTimes.EstimateTime(10)
+	assert.Equal(t, false, ok)
+
+	blockTimes.Add(1, start)
+	blockTimes.Add(2, start.Add(10*time.Second))
+	blockTimes.Add(4, start.Add(20*time.Second))
+	blockTimes.Add(3, start.Add(15*time.Second)) // ignored
+	blockTimes.Add(5, start.Add(31*time.Second))
+
+	assert.Equal(t, 7*time.Second, blockTimes.Average())
+
+	eta, ok := blockTimes.EstimateTime(8)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, start.Add(52*time.Second), eta)
+}
+
+func TestParseUpgradeInfo(t *testing.T) {
+	info, err := ParseUpgradeInfo(`{"binaries":{"linux/arm64":"https://example.com/gaiad-arm64?checksum=sha256:def","linux/amd64":"https://example.com/gaiad-amd64?checksum=sha256:abc"}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []UpgradeBinary{
+		{Platform: "linux/amd64", URL: "https://example.com/gaiad-amd64?checksum=sha256:abc", Checksum: "sha256:abc"},
+		{Platform: "linux/arm64", URL: "https://example.com/gaiad-arm64?checksum=sha256:def", Checksum: "sha256:def"},
+	}, info.SortedBinaries())
+
+	info, err = ParseUpgradeInfo("https://example.com/upgrade-info.json")
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(info.SortedBinaries()))
+
+	_, err = ParseUpgradeInfo(`{"binaries": "oops"}`)
+	assert.ErrorContains(t, err, "failed to decode upgrade info")
+
+	_, err = ParseUpgradeInfo(`{"binaries":{"linux/amd64":"not a url"}}`)
+	assert.ErrorContains(t, err, "invalid binary url")
 }
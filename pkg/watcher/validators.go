@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/cosmos/cosmos-sdk/client"
@@ -22,6 +23,11 @@ type ValidatorsWatcher struct {
 	validators []TrackedValidator
 	pool       *rpc.Pool
 	opts       ValidatorsWatcherOptions
+
+	mu     sync.RWMutex
+	states map[string]ValidatorState // by address
+
+	health *Health
 }
 
 type ValidatorsWatcherOptions struct {
@@ -36,7 +42,29 @@ func NewValidatorsWatcher(validators []TrackedValidator, metrics *metrics.Metric
 		validators: validators,
 		pool:       pool,
 		opts:       opts,
+		states:     make(map[string]ValidatorState),
+		health:     NewHealth("validators", metrics),
+	}
+}
+
+func (w *ValidatorsWatcher) Health() *Health {
+	return w.health
+}
+
+// Validators returns the latest known state of the tracked validators.
+func (w *ValidatorsWatcher) Validators() []ValidatorState {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	states := make([]ValidatorState, len(w.validators))
+	for i, tracked := range w.validators {
+		state, ok := w.states[tracked.Address]
+		if !ok {
+			state = ValidatorState{Address: tracked.Address, Name: tracked.Name}
+		}
+		states[i] = state
 	}
+	return states
 }
 
 func (w *ValidatorsWatcher) Start(ctx context.Context) error {
@@ -46,15 +74,21 @@ func (w *ValidatorsWatcher) Start(ctx context.Context) error {
 		node := w.pool.GetSyncedNode()
 		if node == nil {
 			log.Warn().Msg("no node available to fetch validators")
+			w.health.Report(errNoSyncedNode)
 		} else if err := w.fetchValidators(ctx, node); err != nil {
 			log.Error().Err(err).
 				Str("node", node.Redacted()).
 				Msg("failed to fetch staking validators")
+			w.health.Report(err)
 		} else if err := w.fetchSigningInfos(ctx, node); err != nil {
 			log.Error().Err(err).
 				Str("node", node.Redacted()).
 				Msg("failed to fetch signing infos")
+			w.health.Report(err)
+		} else {
+			w.health.Report(nil)
 		}
+		w.health.Processed(0)
 		select {
 		case <-ctx.Done():
 			return nil
@@ -65,7 +99,7 @@ func (w *ValidatorsWatcher) Start(ctx context.Context) error {
 
 func (w *ValidatorsWatcher) fetchSigningInfos(ctx context.Context, node *rpc.Node) error {
 	if !w.opts.NoSlashing {
-		clientCtx := (client.Context{}).WithClient(node.Client)
+		clientCtx := (client.Context{}).WithClient(node.QueryClient())
 		queryClient := slashing.NewQueryClient(clientCtx)
 		signingInfos, err := queryClient.SigningInfos(ctx, &slashing.QuerySigningInfosRequest{
 			Pagination: &query.PageRequest{
@@ -86,7 +120,7 @@ func (w *ValidatorsWatcher) fetchSigningInfos(ctx context.Context, node *rpc.Nod
 }
 
 func (w *ValidatorsWatcher) fetchValidators(ctx context.Context, node *rpc.Node) error {
-	clientCtx := (client.Context{}).WithClient(node.Client)
+	clientCtx := (client.Context{}).WithClient(node.QueryClient())
 	queryClient := staking.NewQueryClient(clientCtx)
 
 	validators, err := queryClient.Validators(ctx, &staking.QueryValidatorsRequest{
@@ -104,12 +138,19 @@ func (w *ValidatorsWatcher) fetchValidators(ctx context.Context, node *rpc.Node)
 }
 
 func (w *ValidatorsWatcher) handleSigningInfos(chainID string, signingInfos []slashing.ValidatorSigningInfo) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	for _, tracked := range w.validators {
 
 		for _, val := range signingInfos {
 
 			if tracked.ConsensusAddress == val.Address {
 				w.metrics.MissedBlocksWindow.WithLabelValues(chainID, tracked.Address, tracked.Name).Set(float64(val.MissedBlocksCounter))
+				if state, ok := w.states[tracked.Address]; ok {
+					state.MissedBlocksWindow = val.MissedBlocksCounter
+					w.states[tracked.Address] = state
+				}
 				break
 			}
 		}
@@ -135,6 +176,9 @@ func (w *ValidatorsWatcher) handleValidators(chainID string, validators []stakin
 		w.metrics.SeatPrice.WithLabelValues(chainID, w.opts.Denom).Set(seatPrice.InexactFloat64())
 	}
 
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
 	for _, tracked := range w.validators {
 		name := tracked.Name
 
@@ -152,6 +196,18 @@ func (w *ValidatorsWatcher) handleValidators(chainID string, validators []stakin
 				w.metrics.Tokens.WithLabelValues(chainID, address, name, w.opts.Denom).Set(tokens.InexactFloat64())
 				w.metrics.IsBonded.WithLabelValues(chainID, address, name).Set(metrics.BoolToFloat64(isBonded))
 				w.metrics.IsJailed.WithLabelValues(chainID, address, name).Set(metrics.BoolToFloat64(isJailed))
+
+				state := w.states[address]
+				state.Address = address
+				state.Name = name
+				state.Moniker = val.Description.Moniker
+				state.OperatorAddress = val.OperatorAddress
+				state.ConsensusAddress = tracked.ConsensusAddress
+				state.Rank = rank
+				state.Tokens = tokens.InexactFloat64()
+				state.Bonded = isBonded
+				state.Jailed = isJailed
+				w.states[address] = state
 				break
 			}
 		}
@@ -103,5 +103,12 @@ func TestValidatorsWatcher(t *testing.T) {
 		assert.Equal(t, float64(0), testutil.ToFloat64(validatorsWatcher.metrics.IsJailed.WithLabelValues(chainID, kilnAddress, kilnName)))
 
 		assert.Equal(t, float64(3), testutil.ToFloat64(validatorsWatcher.metrics.MissedBlocksWindow.WithLabelValues(chainID, kilnAddress, kilnName)))
+
+		states := validatorsWatcher.Validators()
+		assert.Equal(t, 1, len(states))
+		assert.Equal(t, 2, states[0].Rank)
+		assert.Equal(t, float64(42), states[0].Tokens)
+		assert.Equal(t, true, states[0].Bonded)
+		assert.Equal(t, int64(3), states[0].MissedBlocksWindow)
 	})
 }
@@ -3,13 +3,17 @@ package watcher
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
+	"cosmossdk.io/math"
 	"github.com/cosmos/cosmos-sdk/client"
 	gov "github.com/cosmos/cosmos-sdk/x/gov/types/v1"
 	govbeta "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/rpc"
+	"github.com/kilnfi/cosmos-validator-watcher/pkg/webhook"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -19,22 +23,48 @@ type VotesWatcher struct {
 	metrics    *metrics.Metrics
 	validators []TrackedValidator
 	pool       *rpc.Pool
+	webhook    *webhook.Webhook
 	options    VotesWatcherOptions
+
+	divergences map[string]bool // proposal/validator pairs already reported as diverging
+	endTimes    map[uint64]time.Time
+
+	mu        sync.RWMutex
+	proposals []ProposalState
+
+	health *Health
 }
 
 type VotesWatcherOptions struct {
 	GovModuleVersion string
+	ExpectedVotes    VotePolicy
 }
 
-func NewVotesWatcher(validators []TrackedValidator, metrics *metrics.Metrics, pool *rpc.Pool, options VotesWatcherOptions) *VotesWatcher {
+func NewVotesWatcher(validators []TrackedValidator, metrics *metrics.Metrics, pool *rpc.Pool, webhook *webhook.Webhook, options VotesWatcherOptions) *VotesWatcher {
 	return &VotesWatcher{
-		metrics:    metrics,
-		validators: validators,
-		pool:       pool,
-		options:    options,
+		metrics:     metrics,
+		validators:  validators,
+		pool:        pool,
+		webhook:     webhook,
+		options:     options,
+		divergences: make(map[string]bool),
+		health:      NewHealth("votes", metrics),
 	}
 }
 
+func (w *VotesWatcher) Health() *Health {
+	return w.health
+}
+
+// Proposals returns the proposals in voting period with the votes of the
+// tracked validators.
+func (w *VotesWatcher) Proposals() []ProposalState {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.proposals
+}
+
 func (w *VotesWatcher) Start(ctx context.Context) error {
 	ticker := time.NewTicker(1 * time.Minute)
 
@@ -42,11 +72,16 @@ func (w *VotesWatcher) Start(ctx context.Context) error {
 		node := w.pool.GetSyncedNode()
 		if node == nil {
 			log.Warn().Msg("no node available to fetch proposals")
+			w.health.Report(errNoSyncedNode)
 		} else if err := w.fetchProposals(ctx, node); err != nil {
 			log.Error().Err(err).
 				Str("node", node.Redacted()).
 				Msg("failed to fetch pending proposals")
+			w.health.Report(err)
+		} else {
+			w.health.Report(nil)
 		}
+		w.health.Processed(0)
 
 		select {
 		case <-ctx.Done():
@@ -58,10 +93,12 @@ func (w *VotesWatcher) Start(ctx context.Context) error {
 
 func (w *VotesWatcher) fetchProposals(ctx context.Context, node *rpc.Node) error {
 	var (
-		votes map[uint64]map[TrackedValidator]bool
+		votes map[uint64]map[TrackedValidator]gov.VoteOption
 		err   error
 	)
 
+	w.endTimes = make(map[uint64]time.Time)
+
 	switch w.options.GovModuleVersion {
 	case "v1beta1":
 		votes, err = w.fetchProposalsV1Beta1(ctx, node)
@@ -74,21 +111,56 @@ func (w *VotesWatcher) fetchProposals(ctx context.Context, node *rpc.Node) error
 	}
 
 	w.metrics.Vote.Reset()
+	w.metrics.VotePolicyMatch.Reset()
 	for proposalId, votes := range votes {
-		for validator, voted := range votes {
+		for validator, option := range votes {
 			w.metrics.Vote.
 				WithLabelValues(node.ChainID(), validator.Address, validator.Name, fmt.Sprintf("%d", proposalId)).
-				Set(metrics.BoolToFloat64(voted))
+				Set(metrics.BoolToFloat64(option != gov.OptionEmpty))
+			w.handleVotePolicy(ctx, node.ChainID(), validator, proposalId, option)
 		}
 	}
 
+	w.handleProposals(votes)
+
 	return nil
 }
 
-func (w *VotesWatcher) fetchProposalsV1(ctx context.Context, node *rpc.Node) (map[uint64]map[TrackedValidator]bool, error) {
-	votes := make(map[uint64]map[TrackedValidator]bool)
+func (w *VotesWatcher) handleProposals(votes map[uint64]map[TrackedValidator]gov.VoteOption) {
+	proposals := make([]ProposalState, 0, len(votes))
+	for proposalId, proposalVotes := range votes {
+		proposal := ProposalState{ID: proposalId, VotingEndTime: w.endTimes[proposalId]}
+		for _, validator := range w.validators {
+			option, ok := proposalVotes[validator]
+			if !ok {
+				continue
+			}
+			vote := ProposalVote{
+				Address: validator.Address,
+				Name:    validator.Name,
+				Voted:   option != gov.OptionEmpty,
+			}
+			if vote.Voted {
+				vote.Option = VoteOptionName(option)
+			}
+			if expected, ok := w.options.ExpectedVotes[proposalId]; ok {
+				vote.Expected = VoteOptionName(expected)
+			}
+			proposal.Votes = append(proposal.Votes, vote)
+		}
+		proposals = append(proposals, proposal)
+	}
+	sort.Slice(proposals, func(i, j int) bool { return proposals[i].ID < proposals[j].ID })
+
+	w.mu.Lock()
+	w.proposals = proposals
+	w.mu.Unlock()
+}
+
+func (w *VotesWatcher) fetchProposalsV1(ctx context.Context, node *rpc.Node) (map[uint64]map[TrackedValidator]gov.VoteOption, error) {
+	votes := make(map[uint64]map[TrackedValidator]gov.VoteOption)
 
-	clientCtx := (client.Context{}).WithClient(node.Client)
+	clientCtx := (client.Context{}).WithClient(node.QueryClient())
 	queryClient := gov.NewQueryClient(clientCtx)
 
 	// Fetch all proposals in voting period
@@ -103,7 +175,10 @@ func (w *VotesWatcher) fetchProposalsV1(ctx context.Context, node *rpc.Node) (ma
 
 	// For each proposal, fetch validators vote
 	for _, proposal := range proposalsResp.GetProposals() {
-		votes[proposal.Id] = make(map[TrackedValidator]bool)
+		votes[proposal.Id] = make(map[TrackedValidator]gov.VoteOption)
+		if proposal.VotingEndTime != nil {
+			w.endTimes[proposal.Id] = *proposal.VotingEndTime
+		}
 		w.metrics.ProposalEndTime.WithLabelValues(chainID, fmt.Sprintf("%d", proposal.Id)).Set(float64(proposal.VotingEndTime.Unix()))
 
 		for _, validator := range w.validators {
@@ -118,23 +193,15 @@ func (w *VotesWatcher) fetchProposalsV1(ctx context.Context, node *rpc.Node) (ma
 			})
 
 			if isInvalidArgumentError(err) {
-				votes[proposal.Id][validator] = false
+				votes[proposal.Id][validator] = gov.OptionEmpty
 			} else if err != nil {
-				votes[proposal.Id][validator] = false
+				votes[proposal.Id][validator] = gov.OptionEmpty
 				log.Warn().
 					Str("validator", validator.Name).
 					Str("proposal", fmt.Sprintf("%d", proposal.Id)).
 					Err(err).Msg("failed to get validator vote for proposal")
 			} else {
-				vote := voteResp.GetVote()
-				voted := false
-				for _, option := range vote.Options {
-					if option.Option != gov.OptionEmpty {
-						voted = true
-						break
-					}
-				}
-				votes[proposal.Id][validator] = voted
+				votes[proposal.Id][validator] = mainVoteOptionV1(voteResp.GetVote().Options)
 			}
 		}
 	}
@@ -142,10 +209,10 @@ func (w *VotesWatcher) fetchProposalsV1(ctx context.Context, node *rpc.Node) (ma
 	return votes, nil
 }
 
-func (w *VotesWatcher) fetchProposalsV1Beta1(ctx context.Context, node *rpc.Node) (map[uint64]map[TrackedValidator]bool, error) {
-	votes := make(map[uint64]map[TrackedValidator]bool)
+func (w *VotesWatcher) fetchProposalsV1Beta1(ctx context.Context, node *rpc.Node) (map[uint64]map[TrackedValidator]gov.VoteOption, error) {
+	votes := make(map[uint64]map[TrackedValidator]gov.VoteOption)
 
-	clientCtx := (client.Context{}).WithClient(node.Client)
+	clientCtx := (client.Context{}).WithClient(node.QueryClient())
 	queryClient := govbeta.NewQueryClient(clientCtx)
 
 	// Fetch all proposals in voting period
@@ -160,7 +227,8 @@ func (w *VotesWatcher) fetchProposalsV1Beta1(ctx context.Context, node *rpc.Node
 
 	// For each proposal, fetch validators vote
 	for _, proposal := range proposalsResp.GetProposals() {
-		votes[proposal.ProposalId] = make(map[TrackedValidator]bool)
+		votes[proposal.ProposalId] = make(map[TrackedValidator]gov.VoteOption)
+		w.endTimes[proposal.ProposalId] = proposal.VotingEndTime
 		w.metrics.ProposalEndTime.WithLabelValues(chainID, fmt.Sprintf("%d", proposal.ProposalId)).Set(float64(proposal.VotingEndTime.Unix()))
 
 		for _, validator := range w.validators {
@@ -175,23 +243,15 @@ func (w *VotesWatcher) fetchProposalsV1Beta1(ctx context.Context, node *rpc.Node
 			})
 
 			if isInvalidArgumentError(err) {
-				votes[proposal.ProposalId][validator] = false
+				votes[proposal.ProposalId][validator] = gov.OptionEmpty
 			} else if err != nil {
-				votes[proposal.ProposalId][validator] = false
+				votes[proposal.ProposalId][validator] = gov.OptionEmpty
 				log.Warn().
 					Str("validator", validator.Name).
 					Str("proposal", fmt.Sprintf("%d", proposal.ProposalId)).
 					Err(err).Msg("failed to get validator vote for proposal")
 			} else {
-				vote := voteResp.GetVote()
-				voted := false
-				for _, option := range vote.Options {
-					if option.Option != govbeta.OptionEmpty {
-						voted = true
-						break
-					}
-				}
-				votes[proposal.ProposalId][validator] = voted
+				votes[proposal.ProposalId][validator] = mainVoteOptionV1Beta1(voteResp.GetVote().Options)
 			}
 		}
 	}
@@ -200,19 +260,113 @@ func (w *VotesWatcher) fetchProposalsV1Beta1(ctx context.Context, node *rpc.Node
 }
 
 func (w *VotesWatcher) handleVoteV1Beta1(chainID string, validator TrackedValidator, proposalId uint64, votes []govbeta.WeightedVoteOption) {
-	voted := false
-	for _, option := range votes {
-		if option.Option != govbeta.OptionEmpty {
-			voted = true
-			break
-		}
-	}
+	voted := mainVoteOptionV1Beta1(votes) != gov.OptionEmpty
 
 	w.metrics.Vote.
 		WithLabelValues(chainID, validator.Address, validator.Name, fmt.Sprintf("%d", proposalId)).
 		Set(metrics.BoolToFloat64(voted))
 }
 
+func (w *VotesWatcher) handleVotePolicy(ctx context.Context, chainID string, validator TrackedValidator, proposalId uint64, option gov.VoteOption) {
+	expected, ok := w.options.ExpectedVotes[proposalId]
+	if !ok {
+		return
+	}
+
+	matches := option == expected
+	w.metrics.VotePolicyMatch.
+		WithLabelValues(chainID, validator.Address, validator.Name, fmt.Sprintf("%d", proposalId), VoteOptionName(expected)).
+		Set(metrics.BoolToFloat64(matches))
+
+	// Only report votes that have been cast
+	if option == gov.OptionEmpty || matches {
+		return
+	}
+
+	// Report each diverging vote only once
+	key := fmt.Sprintf("%d/%s/%s", proposalId, validator.Address, VoteOptionName(option))
+	if w.divergences[key] {
+		return
+	}
+	w.divergences[key] = true
+
+	log.Warn().
+		Str("validator", validator.Name).
+		Str("proposal", fmt.Sprintf("%d", proposalId)).
+		Str("expected", VoteOptionName(expected)).
+		Str("actual", VoteOptionName(option)).
+		Msg("validator vote differs from expected vote policy")
+
+	if w.webhook != nil {
+		go w.triggerWebhook(ctx, chainID, validator, proposalId, expected, option)
+	}
+}
+
+func (w *VotesWatcher) triggerWebhook(ctx context.Context, chainID string, validator TrackedValidator, proposalId uint64, expected, actual gov.VoteOption) {
+	msg := struct {
+		Type       string `json:"type"`
+		ChainID    string `json:"chain_id"`
+		ProposalID uint64 `json:"proposal_id"`
+		Address    string `json:"address"`
+		Name       string `json:"name"`
+		Expected   string `json:"expected"`
+		Actual     string `json:"actual"`
+	}{
+		Type:       "vote_divergence",
+		ChainID:    chainID,
+		ProposalID: proposalId,
+		Address:    validator.Address,
+		Name:       validator.Name,
+		Expected:   VoteOptionName(expected),
+		Actual:     VoteOptionName(actual),
+	}
+
+	if err := w.webhook.Send(ctx, msg); err != nil {
+		log.Error().Err(err).Msg("failed to send vote divergence webhook")
+	}
+}
+
+// mainVoteOptionV1 returns the option with the highest weight (OptionEmpty if none).
+func mainVoteOptionV1(options []*gov.WeightedVoteOption) gov.VoteOption {
+	main := gov.OptionEmpty
+	weight := math.LegacyZeroDec()
+	for _, option := range options {
+		if option == nil || option.Option == gov.OptionEmpty {
+			continue
+		}
+		w, err := math.LegacyNewDecFromStr(option.Weight)
+		if err != nil {
+			// Weight is not always set, consider it as a full vote
+			w = math.LegacyOneDec()
+		}
+		if main == gov.OptionEmpty || w.GT(weight) {
+			main = option.Option
+			weight = w
+		}
+	}
+	return main
+}
+
+// mainVoteOptionV1Beta1 returns the option with the highest weight (OptionEmpty if none).
+func mainVoteOptionV1Beta1(options []govbeta.WeightedVoteOption) gov.VoteOption {
+	main := gov.OptionEmpty
+	weight := math.LegacyZeroDec()
+	for _, option := range options {
+		if option.Option == govbeta.OptionEmpty {
+			continue
+		}
+		w := option.Weight
+		if w.IsNil() {
+			w = math.LegacyOneDec()
+		}
+		if main == gov.OptionEmpty || w.GT(weight) {
+			main = gov.VoteOption(option.Option)
+			weight = w
+		}
+	}
+	return main
+}
+
 func isInvalidArgumentError(err error) bool {
 	st, ok := status.FromError(err)
 	if !ok {
@@ -0,0 +1,100 @@
+package watcher
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	gov "github.com/cosmos/cosmos-sdk/x/gov/types/v1"
+)
+
+// VotePolicy maps proposal IDs to the option validators are expected to vote.
+type VotePolicy map[uint64]gov.VoteOption
+
+// LoadVotePolicy reads a vote policy file.
+//
+// The file contains one `<proposal-id>:<option>` entry per line, where option
+// is one of yes, no, abstain or no_with_veto. Empty lines and lines starting
+// with # are ignored.
+func LoadVotePolicy(path string) (VotePolicy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vote policy: %w", err)
+	}
+	defer f.Close()
+
+	return ParseVotePolicy(f)
+}
+
+func ParseVotePolicy(r io.Reader) (VotePolicy, error) {
+	policy := make(VotePolicy)
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid vote policy entry on line %d: %s", line, entry)
+		}
+
+		proposalId, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proposal id on line %d: %w", line, err)
+		}
+
+		option, err := ParseVoteOption(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid vote option on line %d: %w", line, err)
+		}
+
+		policy[proposalId] = option
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vote policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// ParseVoteOption accepts both short (yes) and full (VOTE_OPTION_YES) option names.
+func ParseVoteOption(val string) (gov.VoteOption, error) {
+	name := strings.ToLower(strings.TrimSpace(val))
+	name = strings.TrimPrefix(name, "vote_option_")
+
+	switch name {
+	case "yes":
+		return gov.OptionYes, nil
+	case "no":
+		return gov.OptionNo, nil
+	case "abstain":
+		return gov.OptionAbstain, nil
+	case "no_with_veto", "nowithveto", "veto":
+		return gov.OptionNoWithVeto, nil
+	}
+
+	return gov.OptionEmpty, fmt.Errorf("unknown vote option: %s", val)
+}
+
+// VoteOptionName returns the short name of a vote option (eg. yes, no_with_veto).
+func VoteOptionName(option gov.VoteOption) string {
+	switch option {
+	case gov.OptionYes:
+		return "yes"
+	case gov.OptionNo:
+		return "no"
+	case gov.OptionAbstain:
+		return "abstain"
+	case gov.OptionNoWithVeto:
+		return "no_with_veto"
+	}
+	return ""
+}
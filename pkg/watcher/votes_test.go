@@ -1,8 +1,12 @@
 package watcher
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 
+	govv1 "github.com/cosmos/cosmos-sdk/x/gov/types/v1"
 	gov "github.com/cosmos/cosmos-sdk/x/gov/types/v1beta1"
 	"github.com/kilnfi/cosmos-validator-watcher/pkg/metrics"
 	"github.com/prometheus/client_golang/prometheus/testutil"
@@ -26,8 +30,13 @@ func TestVotesWatcher(t *testing.T) {
 		validators,
 		metrics.New("cosmos_validator_watcher"),
 		nil,
+		nil,
 		VotesWatcherOptions{
 			GovModuleVersion: "v1beta1",
+			ExpectedVotes: VotePolicy{
+				42: govv1.OptionYes,
+				43: govv1.OptionNo,
+			},
 		},
 	)
 
@@ -40,4 +49,55 @@ func TestVotesWatcher(t *testing.T) {
 		assert.Equal(t, float64(0), testutil.ToFloat64(votesWatcher.metrics.Vote.WithLabelValues(chainID, kilnAddress, kilnName, "41")))
 		assert.Equal(t, float64(1), testutil.ToFloat64(votesWatcher.metrics.Vote.WithLabelValues(chainID, kilnAddress, kilnName, "42")))
 	})
+
+	t.Run("Handle Vote Policy", func(t *testing.T) {
+		ctx := context.Background()
+		votesWatcher.handleVotePolicy(ctx, chainID, validators[0], 41, govv1.OptionYes)
+		votesWatcher.handleVotePolicy(ctx, chainID, validators[0], 42, govv1.OptionYes)
+		votesWatcher.handleVotePolicy(ctx, chainID, validators[0], 43, govv1.OptionYes)
+
+		assert.Equal(t, 2, testutil.CollectAndCount(votesWatcher.metrics.VotePolicyMatch))
+		assert.Equal(t, float64(1), testutil.ToFloat64(votesWatcher.metrics.VotePolicyMatch.WithLabelValues(chainID, kilnAddress, kilnName, "42", "yes")))
+		assert.Equal(t, float64(0), testutil.ToFloat64(votesWatcher.metrics.VotePolicyMatch.WithLabelValues(chainID, kilnAddress, kilnName, "43", "no")))
+		assert.Equal(t, true, votesWatcher.divergences["43/"+kilnAddress+"/yes"])
+	})
+
+	t.Run("Handle Proposals", func(t *testing.T) {
+		votesWatcher.endTimes = map[uint64]time.Time{}
+		votesWatcher.handleProposals(map[uint64]map[TrackedValidator]govv1.VoteOption{
+			42: {validators[0]: govv1.OptionYes},
+			41: {validators[0]: govv1.OptionEmpty},
+		})
+
+		proposals := votesWatcher.Proposals()
+		assert.Equal(t, 2, len(proposals))
+		assert.DeepEqual(t, []ProposalVote{{Address: kilnAddress, Name: kilnName, Voted: false}}, proposals[0].Votes)
+		assert.DeepEqual(t, []ProposalVote{{Address: kilnAddress, Name: kilnName, Voted: true, Option: "yes", Expected: "yes"}}, proposals[1].Votes)
+	})
+
+	t.Run("Main Vote Option", func(t *testing.T) {
+		assert.Equal(t, govv1.OptionEmpty, mainVoteOptionV1(nil))
+		assert.Equal(t, govv1.OptionNo, mainVoteOptionV1([]*govv1.WeightedVoteOption{
+			{Option: govv1.OptionYes, Weight: "0.3"},
+			{Option: govv1.OptionNo, Weight: "0.7"},
+		}))
+	})
+}
+
+func TestParseVotePolicy(t *testing.T) {
+	policy, err := ParseVotePolicy(strings.NewReader(`
+# governance decisions
+42:yes
+43: no_with_veto
+44:VOTE_OPTION_ABSTAIN
+`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, VotePolicy{
+		42: govv1.OptionYes,
+		43: govv1.OptionNoWithVeto,
+		44: govv1.OptionAbstain,
+	}, policy)
+
+	_, err = ParseVotePolicy(strings.NewReader("42:maybe"))
+	assert.ErrorContains(t, err, "line 1")
 }